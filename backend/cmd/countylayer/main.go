@@ -0,0 +1,76 @@
+// Command countylayer simplifies a US county boundary GeoJSON, such as the Census
+// cartographic boundary file cb_2023_us_county_20m converted with
+// `ogr2ogr -f GeoJSON counties.geojson cb_2023_us_county_20m.shp`, into a gzipped layer
+// for county and FIPS lookups. Written to internal/geo/boundaries/us_counties.geojson.gz it
+// is embedded in the server; elsewhere the server loads it with -counties.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
+)
+
+func main() {
+	in := flag.String("in", "", "county boundary GeoJSON to simplify")
+	out := flag.String("out", "us_counties.geojson.gz", "gzipped GeoJSON to write")
+	tolerance := flag.Float64("tolerance", 0.005, "Douglas-Peucker tolerance in degrees (0.005 is about 500 m)")
+	decimals := flag.Int("decimals", 4, "decimal places kept in coordinates")
+	flag.Parse()
+	if *in == "" {
+		log.Fatal("Usage: countylayer -in counties.geojson [-out file.geojson.gz]")
+	}
+
+	content, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	layer, err := geo.ParseLayer(content)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fc := geo.NewFeatureCollection()
+	vertices := 0
+	for _, b := range layer.Boundaries {
+		shape := b.Shape.Simplify(*tolerance, *decimals)
+		if len(shape) == 0 {
+			log.Printf("Warning: %s (%s) vanished when simplified; keeping it unsimplified", b.Name, b.FIPS)
+			shape = b.Shape
+		}
+		for _, p := range shape {
+			for _, r := range p {
+				vertices += len(r)
+			}
+		}
+		coords, err := json.Marshal(shape)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fc.Features = append(fc.Features, geo.Feature{
+			Type:       "Feature",
+			Geometry:   geo.Geometry{Type: "MultiPolygon", Coordinates: coords},
+			Properties: map[string]interface{}{"name": b.Name, "fips": b.FIPS},
+		})
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	if err := json.NewEncoder(zw).Encode(fc); err != nil {
+		log.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %d counties with %d vertices to %s", len(fc.Features), vertices, *out)
+}
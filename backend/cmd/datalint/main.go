@@ -0,0 +1,51 @@
+// Command datalint validates the site CSVs against their schemas and exits non-zero when
+// any row has an error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+)
+
+func main() {
+	possible := flag.String("possible", "us_possible_locations.csv", "candidate locations CSV")
+	existing := flag.String("existing", "us_datacenters.csv", "existing datacenters CSV")
+	warnings := flag.Bool("warnings", true, "print warnings as well as errors")
+	flag.Parse()
+
+	failed := false
+	for _, f := range []struct {
+		path   string
+		schema data.Schema
+	}{{*possible, data.PossibleLocationsSchema}, {*existing, data.ExistingDatacentersSchema}} {
+		ds, err := data.LoadDataset(f.path, f.schema)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			failed = true
+			continue
+		}
+
+		errs, warns := 0, 0
+		for _, d := range ds.Diagnostics {
+			if d.Severity == data.SeverityError {
+				errs++
+			} else {
+				warns++
+				if !*warnings {
+					continue
+				}
+			}
+			fmt.Println(d)
+		}
+		fmt.Printf("%s: %d rows loaded, %d errors, %d warnings\n", f.path, len(ds.Rows), errs, warns)
+		failed = failed || ds.HasErrors()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
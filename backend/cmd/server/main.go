@@ -1,15 +1,117 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/handlers"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/user"
 )
 
 func main() {
+	envTables := flag.String("env-tables", "", "directory with a manifest.json of environmental tables (defaults to the embedded tables)")
+	states := flag.String("states", "", "state boundary GeoJSON (e.g. Census cb_2023_us_state_5m) replacing the embedded simplified outlines")
+	counties := flag.String("counties", "", "county boundary GeoJSON, optionally gzipped, replacing the embedded county layer")
+	countries := flag.String("countries", "", "country boundary GeoJSON (ISO 3166-1 codes) used to resolve sites outside the US")
+	subdivisions := flag.String("subdivisions", "", "province/region boundary GeoJSON (ISO 3166-2 codes) used with -countries")
+	scenarioDir := flag.String("scenarios", "", "directory of extra climate baseline scenario JSON files")
+	regionsFile := flag.String("regions", "", "JSON list of per-country site datasets and tables to load alongside the US files")
+	watchInterval := flag.Duration("watch-datasets", 5*time.Second, "how often to check the site CSVs for changes (0 disables)")
+	flag.StringVar(&handlers.HeatmapCacheDir, "tile-cache", handlers.HeatmapCacheDir, "directory for rendered heatmap tiles (empty disables the cache)")
+	flag.StringVar(&handlers.AdminToken, "admin-token", os.Getenv("ADMIN_TOKEN"), "bearer token for the admin endpoints (defaults to $ADMIN_TOKEN; empty disables them)")
+	flag.Parse()
+
+	// Cart items feed the neighbour index used for datacenter density.
+	if err := cart.LoadAllCarts(); err != nil {
+		log.Printf("Warning: could not load carts: %v", err)
+	}
+
+	if err := scoring.LoadAllProfiles(); err != nil {
+		log.Printf("Warning: could not load scoring profiles: %v", err)
+	}
+
+	if *envTables != "" {
+		p, err := data.LoadTableProviderDir(*envTables)
+		if err != nil {
+			log.Fatalf("Error loading environmental tables: %v\n", err)
+		}
+		data.SetEnvironmentalProvider(p)
+	}
+
+	if *scenarioDir != "" {
+		if err := data.LoadScenarioDir(*scenarioDir); err != nil {
+			log.Fatalf("Error loading climate scenarios: %v\n", err)
+		}
+	}
+
+	if *states != "" {
+		if err := geo.DefaultResolver().LoadStates(*states); err != nil {
+			log.Fatalf("Error loading state boundaries: %v\n", err)
+		}
+	}
+	if *counties != "" {
+		if err := geo.DefaultResolver().LoadCounties(*counties); err != nil {
+			log.Fatalf("Error loading county boundaries: %v\n", err)
+		}
+	}
+	if !geo.DefaultResolver().HasCounties() {
+		log.Printf("Warning: no county boundaries loaded; county and county_fips stay empty until internal/geo/boundaries/us_counties.geojson.gz is built with cmd/countylayer or -counties is passed")
+	}
+
+	if *countries != "" {
+		if err := geo.DefaultResolver().LoadCountries(*countries); err != nil {
+			log.Fatalf("Error loading country boundaries: %v\n", err)
+		}
+	}
+	if *subdivisions != "" {
+		if err := geo.DefaultResolver().LoadSubdivisions(*subdivisions); err != nil {
+			log.Fatalf("Error loading subdivision boundaries: %v\n", err)
+		}
+	}
+
+	datasets := data.DefaultRegistry()
+	if err := datasets.Reload(); err != nil {
+		log.Fatalf("Error loading datasets: %v\n", err)
+	}
+	if snapshot, err := datasets.Snapshot(); err == nil {
+		for _, d := range append(snapshot.PossibleLocations.Diagnostics, snapshot.ExistingDatacenters.Diagnostics...) {
+			log.Printf("Warning: %v", d)
+		}
+	}
+	if err := datasets.LoadNamed(); err != nil {
+		log.Printf("Warning: could not load uploaded datasets: %v", err)
+	}
+	if *watchInterval > 0 {
+		go datasets.Watch(*watchInterval, nil)
+	}
+
+	if *regionsFile != "" {
+		configs, err := data.LoadRegions(*regionsFile)
+		if err != nil {
+			log.Fatalf("Error loading regions: %v\n", err)
+		}
+		for _, c := range configs {
+			registry, err := data.AddRegion(c)
+			if err != nil {
+				log.Fatalf("Error loading regions: %v\n", err)
+			}
+			if err := registry.LoadNamed(); err != nil {
+				log.Printf("Warning: could not load uploaded datasets for %s: %v", c.Country, err)
+			}
+			if *watchInterval > 0 {
+				go registry.Watch(*watchInterval, nil)
+			}
+		}
+	}
+
 	// Example usage of your “load users, define routes, start server” logic
 	err := user.LoadUserPasswords("users.txt")
 	if err != nil {
@@ -23,8 +125,24 @@ func main() {
 	http.HandleFunc("/alldatacenters", handlers.AllDataCentersHandler)
 	http.HandleFunc("/api/possible-datacenters", handlers.PossibleDataCenterHandler)
 	http.HandleFunc("/api/property-details", handlers.GetPropertyDetailsHandler)
+	http.HandleFunc("/api/score", handlers.ScoreCoordinateHandler)
+	http.HandleFunc("/api/sites/rank", handlers.RankSitesHandler)
+	http.HandleFunc("/api/sensitivity", handlers.SensitivityHandler)
+	http.HandleFunc("/api/optimize", handlers.OptimizeHandler)
+	http.HandleFunc("/api/scoring-profiles", handlers.ScoringProfilesHandler)
+	http.HandleFunc("/api/datasets", handlers.DatasetsHandler)
+	http.HandleFunc("/api/regions", handlers.RegionsHandler)
+	http.HandleFunc("/tiles/", handlers.TilesHandler)
+	http.HandleFunc("/heatmap/", handlers.HeatmapHandler)
+	http.HandleFunc("/api/admin/reload-datasets", handlers.ReloadDatasetsHandler)
 	http.HandleFunc("/cart/add", handlers.AddToCartHandler)
-	http.HandleFunc("/cart/item", handlers.DeleteCartItemHandler)
+	http.HandleFunc("/cart/item", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			handlers.UpdateCartItemHandler(w, r)
+		} else {
+			handlers.DeleteCartItemHandler(w, r)
+		}
+	})
 	http.HandleFunc("/cart", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodDelete {
 			handlers.DeleteCartHandler(w, r)
@@ -35,6 +153,8 @@ func main() {
 		}
 	})
 	http.HandleFunc("/api/simulation", handlers.GetUserClimateSimulationHandler)
+	http.HandleFunc("/api/simulation/compare", handlers.CompareSimulationHandler)
+	http.HandleFunc("/api/scenarios", handlers.ScenariosHandler)
 	http.HandleFunc("/cart/carbon-footprint", handlers.GetCarbonFootprintHandler)
 
 	fmt.Println("Starting server on :8080 ...")
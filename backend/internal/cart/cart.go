@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
 )
@@ -17,6 +18,9 @@ var (
 	carts   = make(map[string]*Cart)
 	cartMu  sync.RWMutex
 	cartDir = "./carts" // directory where cart files are stored
+
+	// version counts changes to any cart, so indexes built from AllItems know when to rebuild.
+	version atomic.Uint64
 )
 
 type CartItem = data.DatacenterLocation
@@ -59,6 +63,7 @@ func LoadAllCarts() error {
 		}
 		cartMu.Lock()
 		carts[username] = &c
+		version.Add(1)
 		cartMu.Unlock()
 	}
 	return nil
@@ -86,8 +91,30 @@ func GetCart(username string) (*Cart, bool) {
 	return c, ok
 }
 
+// Version returns a number that changes whenever an item is added to, removed from or
+// rescheduled in any cart.
+func Version() uint64 {
+	return version.Load()
+}
+
+// AllItems returns a copy of every item across all users' carts.
+func AllItems() []CartItem {
+	cartMu.RLock()
+	defer cartMu.RUnlock()
+	var items []CartItem
+	for _, c := range carts {
+		items = append(items, c.Items...)
+	}
+	return items
+}
+
 // AddToCart adds a datacenter item to the user's cart and deducts the cost.
 func AddToCart(username string, item data.DatacenterLocation, cost float64) error {
+	if item.Schedule != nil {
+		if err := item.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
 	cartMu.Lock()
 	defer cartMu.Unlock()
 	c, exists := carts[username]
@@ -105,6 +132,7 @@ func AddToCart(username string, item data.DatacenterLocation, cost float64) erro
 	}
 	c.Items = append(c.Items, item)
 	c.MoneyLeft -= cost
+	version.Add(1)
 	// Use the no-lock version since the write lock is held.
 	return SaveCartNoLock(username, c)
 }
@@ -125,6 +153,29 @@ func RemoveItemFromCart(username string, index int) error {
 
 	// Remove the item by slicing it out
 	c.Items = append(c.Items[:index], c.Items[index+1:]...)
+	version.Add(1)
+	return SaveCartNoLock(username, c)
+}
+
+// SetItemSchedule sets when the item at the given index operates; nil clears the schedule.
+func SetItemSchedule(username string, index int, schedule *data.OperatingSchedule) error {
+	if schedule != nil {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	cartMu.Lock()
+	defer cartMu.Unlock()
+
+	c, exists := carts[username]
+	if !exists {
+		return fmt.Errorf("cart not found for user %s", username)
+	}
+	if index < 0 || index >= len(c.Items) {
+		return fmt.Errorf("invalid index %d", index)
+	}
+	c.Items[index].Schedule = schedule
+	version.Add(1)
 	return SaveCartNoLock(username, c)
 }
 
@@ -135,6 +186,7 @@ func DeleteCart(username string) error {
 
 	// Remove from in-memory map
 	delete(carts, username)
+	version.Add(1)
 
 	// Remove the file on disk.
 	path := filepath.Join(cartDir, username+".cart")
@@ -0,0 +1,59 @@
+package data
+
+import "math"
+
+// DefaultTCRE is the transient climate response to cumulative CO2 emissions, in °C per
+// tonne of CO2: the IPCC AR6 best estimate of 1.65 °C per 1000 PgC, or 0.45 °C per
+// 1000 GtCO2 (likely range 0.27-0.63).
+const DefaultTCRE = 0.45e-12
+
+// DecayBox is a share of each year's emissions that is drawn down with an e-folding
+// timescale, in years.
+type DecayBox struct {
+	Share     float64 `json:"share"`
+	Timescale float64 `json:"timescale"`
+}
+
+// TwoBoxDecay lumps the carbon-cycle impulse response of Joos et al. (2013) into two
+// boxes, ocean mixed layer and biosphere uptake; the rest of each pulse, including the
+// century-scale part, persists over a simulation horizon.
+var TwoBoxDecay = []DecayBox{
+	{Share: 0.28, Timescale: 36.5},
+	{Share: 0.28, Timescale: 4.3},
+}
+
+// ClimateResponse maps emissions to warming: TCRE times the cumulative emissions still in
+// the atmosphere. Without decay boxes every tonne stays, so warming never falls after a
+// facility closes.
+type ClimateResponse struct {
+	TCRE  float64    `json:"tcre_per_tonne"` // °C per tonne CO2e; the tcre query parameter is per 1000 GtCO2
+	Decay []DecayBox `json:"decay,omitempty"`
+}
+
+// Warming returns the warming in each year from emissions, one value in tonnes per year,
+// and the cumulative emissions remaining in the atmosphere at the end of each year.
+func (c ClimateResponse) Warming(emissions []float64) (warming, cumulative []float64) {
+	persistent := 1.0
+	decay := make([]float64, len(c.Decay))
+	for i, b := range c.Decay {
+		persistent -= b.Share
+		decay[i] = math.Exp(-1 / b.Timescale)
+	}
+	persistent = math.Max(0, persistent)
+
+	warming = make([]float64, len(emissions))
+	cumulative = make([]float64, len(emissions))
+	stock := 0.0
+	boxes := make([]float64, len(c.Decay))
+	for t, e := range emissions {
+		stock += persistent * e
+		total := stock
+		for i, b := range c.Decay {
+			boxes[i] = boxes[i]*decay[i] + b.Share*e
+			total += boxes[i]
+		}
+		cumulative[t] = total
+		warming[t] = c.TCRE * total
+	}
+	return warming, cumulative
+}
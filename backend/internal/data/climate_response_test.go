@@ -0,0 +1,57 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClimateResponseWarming(t *testing.T) {
+	e1 := math.Exp(-1)
+	tests := []struct {
+		name           string
+		response       ClimateResponse
+		emissions      []float64
+		wantCumulative []float64
+	}{
+		{"no emissions", ClimateResponse{TCRE: DefaultTCRE}, nil, []float64{}},
+		{"no decay keeps every tonne", ClimateResponse{TCRE: DefaultTCRE}, []float64{100, 0, 50}, []float64{100, 100, 150}},
+		{"one box", ClimateResponse{TCRE: 1, Decay: []DecayBox{{Share: 1, Timescale: 1}}}, []float64{1, 0, 0}, []float64{1, e1, e1 * e1}},
+		{"partial box", ClimateResponse{TCRE: 1, Decay: []DecayBox{{Share: 0.5, Timescale: 1}}}, []float64{2, 2}, []float64{2, 1 + 2 + e1}},
+		{"shares above 1 leave nothing persistent", ClimateResponse{TCRE: 1, Decay: []DecayBox{{Share: 0.8, Timescale: 1}, {Share: 0.8, Timescale: 1}}}, []float64{1, 0}, []float64{1.6, 1.6 * e1}},
+	}
+	for _, tt := range tests {
+		warming, cumulative := tt.response.Warming(tt.emissions)
+		if len(warming) != len(tt.emissions) || len(cumulative) != len(tt.emissions) {
+			t.Errorf("%s: got %d warming and %d cumulative values for %d years", tt.name, len(warming), len(cumulative), len(tt.emissions))
+			continue
+		}
+		for i, want := range tt.wantCumulative {
+			if math.Abs(cumulative[i]-want) > 1e-9*math.Max(1, want) {
+				t.Errorf("%s: cumulative[%d] = %g, want %g", tt.name, i, cumulative[i], want)
+			}
+			if w := tt.response.TCRE * want; math.Abs(warming[i]-w) > 1e-9*math.Max(1e-12, w) {
+				t.Errorf("%s: warming[%d] = %g, want %g", tt.name, i, warming[i], w)
+			}
+		}
+	}
+}
+
+func TestClimateResponseTwoBoxDecay(t *testing.T) {
+	r := ClimateResponse{TCRE: DefaultTCRE, Decay: TwoBoxDecay}
+	emissions := make([]float64, 500)
+	emissions[0] = 1e9
+	warming, cumulative := r.Warming(emissions)
+
+	if cumulative[0] != 1e9 {
+		t.Errorf("pulse year keeps %g t, want all of it", cumulative[0])
+	}
+	for i := 1; i < len(cumulative); i++ {
+		if cumulative[i] > cumulative[i-1] || warming[i] > warming[i-1] {
+			t.Fatalf("year %d: emissions remaining rose after the pulse", i)
+		}
+	}
+	persistent := 1e9 * (1 - 0.28 - 0.28)
+	if last := cumulative[len(cumulative)-1]; math.Abs(last-persistent) > 1e-5*persistent {
+		t.Errorf("after %d years %g t remain, want the persistent %g t", len(cumulative), last, persistent)
+	}
+}
@@ -0,0 +1,114 @@
+package data
+
+import "math"
+
+// CoolingTechnology is the heat-rejection design of a facility.
+type CoolingTechnology string
+
+const (
+	CoolingAir          CoolingTechnology = "air"           // air-side economizer with DX trim
+	CoolingEvaporative  CoolingTechnology = "evaporative"   // direct/indirect evaporative
+	CoolingChilledWater CoolingTechnology = "chilled-water" // chillers with cooling towers and waterside economizer
+	CoolingLiquid       CoolingTechnology = "liquid"        // direct-to-chip warm-water liquid cooling
+	CoolingImmersion    CoolingTechnology = "immersion"
+)
+
+// coolingModel gives the facility overhead and site water for one month of weather.
+type coolingModel func(n ClimateNormal) (pue, wue float64)
+
+var coolingModels = map[CoolingTechnology]coolingModel{
+	// Free cooling below the supply setpoint; compressors take over as it warms.
+	CoolingAir: func(n ClimateNormal) (float64, float64) {
+		pue := 1.12 + 0.025*math.Max(0, n.DryBulb-15)
+		wue := 0.05 + 0.01*math.Max(0, n.DryBulb-24)
+		return pue, wue
+	},
+	// Limited by wet bulb; evaporation rises with heat and dry air.
+	CoolingEvaporative: func(n ClimateNormal) (float64, float64) {
+		pue := 1.12 + 0.02*math.Max(0, n.WetBulb-12)
+		wue := 0.4 + 0.08*math.Max(0, n.DryBulb-10) + 0.05*(n.DryBulb-n.WetBulb)
+		return pue, wue
+	},
+	// Waterside economizer below 7 °C wet bulb; towers evaporate year round.
+	CoolingChilledWater: func(n ClimateNormal) (float64, float64) {
+		pue := 1.30 + 0.015*math.Max(0, n.WetBulb-10)
+		if n.WetBulb < 7 {
+			pue = 1.22
+		}
+		wue := 1.2 + 0.05*math.Max(0, n.WetBulb-10)
+		return pue, wue
+	},
+	// Warm-water loops reject to dry coolers except on the hottest days.
+	CoolingLiquid: func(n ClimateNormal) (float64, float64) {
+		pue := 1.08 + 0.01*math.Max(0, n.DryBulb-30)
+		wue := 0.05 + 0.02*math.Max(0, n.DryBulb-30)
+		return pue, wue
+	},
+	CoolingImmersion: func(n ClimateNormal) (float64, float64) {
+		pue := 1.04 + 0.005*math.Max(0, n.DryBulb-30)
+		return pue, 0.02
+	},
+}
+
+// MonthlyCooling is the modelled PUE and WUE for one month.
+type MonthlyCooling struct {
+	ClimateNormal
+	PUE float64 `json:"pue"`
+	WUE float64 `json:"wue"` // litres per kWh of IT energy
+}
+
+// CoolingPerformance is the yearly and per-month result of the cooling model.
+type CoolingPerformance struct {
+	Technology CoolingTechnology `json:"technology"`
+	AnnualPUE  float64           `json:"annual_pue"`
+	AnnualWUE  float64           `json:"annual_wue"`
+	Monthly    []MonthlyCooling  `json:"monthly"`
+}
+
+var daysInMonth = [12]float64{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// ModelCooling runs the cooling model for tech over the monthly normals. Annual
+// figures are day-weighted means of the months.
+func ModelCooling(tech CoolingTechnology, normals []ClimateNormal) CoolingPerformance {
+	model, ok := coolingModels[tech]
+	if !ok {
+		model = coolingModels[CoolingChilledWater]
+	}
+	perf := CoolingPerformance{Technology: tech}
+	var days float64
+	for i, n := range normals {
+		pue, wue := model(n)
+		perf.Monthly = append(perf.Monthly, MonthlyCooling{ClimateNormal: n, PUE: pue, WUE: wue})
+
+		d := 30.4
+		if i < len(daysInMonth) {
+			d = daysInMonth[i]
+		}
+		perf.AnnualPUE += pue * d
+		perf.AnnualWUE += wue * d
+		days += d
+	}
+	if days > 0 {
+		perf.AnnualPUE /= days
+		perf.AnnualWUE /= days
+	}
+	return perf
+}
+
+// Scale adjusts the curves so the annual figures match the given design values while
+// keeping their seasonal shape. Zero leaves a figure unchanged.
+func (c *CoolingPerformance) Scale(designPUE, designWUE float64) {
+	pueFactor, wueFactor := 1.0, 1.0
+	if designPUE > 0 && c.AnnualPUE > 1 {
+		pueFactor = (designPUE - 1) / (c.AnnualPUE - 1)
+	}
+	if designWUE > 0 && c.AnnualWUE > 0 {
+		wueFactor = designWUE / c.AnnualWUE
+	}
+	for i := range c.Monthly {
+		c.Monthly[i].PUE = 1 + (c.Monthly[i].PUE-1)*pueFactor
+		c.Monthly[i].WUE *= wueFactor
+	}
+	c.AnnualPUE = 1 + (c.AnnualPUE-1)*pueFactor
+	c.AnnualWUE *= wueFactor
+}
@@ -0,0 +1,82 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+// constantClimate is twelve months of the same weather.
+func constantClimate(dryBulb, wetBulb float64) []ClimateNormal {
+	normals := make([]ClimateNormal, 12)
+	for i := range normals {
+		normals[i] = ClimateNormal{Month: i + 1, DryBulb: dryBulb, WetBulb: wetBulb}
+	}
+	return normals
+}
+
+func TestModelCooling(t *testing.T) {
+	mild, hot := constantClimate(12, 8), constantClimate(35, 24)
+	for tech := range coolingModels {
+		m, h := ModelCooling(tech, mild), ModelCooling(tech, hot)
+		if len(m.Monthly) != 12 || m.Technology != tech {
+			t.Errorf("%s: %d months, technology %q", tech, len(m.Monthly), m.Technology)
+		}
+		if m.AnnualPUE < 1 || h.AnnualPUE < m.AnnualPUE || h.AnnualWUE < m.AnnualWUE {
+			t.Errorf("%s: mild PUE %.3f WUE %.3f, hot PUE %.3f WUE %.3f", tech, m.AnnualPUE, m.AnnualWUE, h.AnnualPUE, h.AnnualWUE)
+		}
+	}
+
+	pue := func(tech CoolingTechnology) float64 { return ModelCooling(tech, hot).AnnualPUE }
+	wue := func(tech CoolingTechnology) float64 { return ModelCooling(tech, hot).AnnualWUE }
+	if !(pue(CoolingImmersion) < pue(CoolingLiquid) && pue(CoolingLiquid) < pue(CoolingChilledWater)) {
+		t.Errorf("PUE in heat: immersion %.3f, liquid %.3f, chilled water %.3f", pue(CoolingImmersion), pue(CoolingLiquid), pue(CoolingChilledWater))
+	}
+	if !(wue(CoolingAir) < wue(CoolingChilledWater) && wue(CoolingChilledWater) < wue(CoolingEvaporative)) {
+		t.Errorf("WUE in heat: air %.3f, chilled water %.3f, evaporative %.3f", wue(CoolingAir), wue(CoolingChilledWater), wue(CoolingEvaporative))
+	}
+
+	if got, want := ModelCooling("seawater", hot).AnnualPUE, pue(CoolingChilledWater); got != want {
+		t.Errorf("unknown technology PUE %.3f, want the chilled-water %.3f", got, want)
+	}
+}
+
+func TestModelCoolingWeightsMonthsByDays(t *testing.T) {
+	// Warm only in February, the shortest month.
+	normals := constantClimate(10, 5)
+	normals[1] = ClimateNormal{Month: 2, DryBulb: 35, WetBulb: 24}
+	perf := ModelCooling(CoolingAir, normals)
+	var want float64
+	for i, m := range perf.Monthly {
+		want += m.PUE * daysInMonth[i]
+	}
+	want /= 365
+	if math.Abs(perf.AnnualPUE-want) > 1e-12 {
+		t.Errorf("annual PUE %.6f, want %.6f", perf.AnnualPUE, want)
+	}
+}
+
+func TestCoolingScale(t *testing.T) {
+	normals := constantClimate(10, 5)
+	normals[6] = ClimateNormal{Month: 7, DryBulb: 32, WetBulb: 22}
+	perf := ModelCooling(CoolingChilledWater, normals)
+	july, january := perf.Monthly[6], perf.Monthly[0]
+
+	perf.Scale(1.2, 0.5)
+	if math.Abs(perf.AnnualPUE-1.2) > 1e-9 || math.Abs(perf.AnnualWUE-0.5) > 1e-9 {
+		t.Errorf("scaled to PUE %.4f WUE %.4f, want 1.2 and 0.5", perf.AnnualPUE, perf.AnnualWUE)
+	}
+	// The seasonal shape is kept: overhead and water scale by the same factor every month.
+	before := (july.PUE - 1) / (january.PUE - 1)
+	after := (perf.Monthly[6].PUE - 1) / (perf.Monthly[0].PUE - 1)
+	if math.Abs(before-after) > 1e-9 || math.Abs(july.WUE/january.WUE-perf.Monthly[6].WUE/perf.Monthly[0].WUE) > 1e-9 {
+		t.Errorf("seasonal shape changed: PUE overhead ratio %.4f to %.4f", before, after)
+	}
+
+	unscaled := ModelCooling(CoolingChilledWater, normals)
+	perf = unscaled
+	perf.Monthly = append([]MonthlyCooling(nil), unscaled.Monthly...)
+	perf.Scale(0, 0)
+	if perf.AnnualPUE != unscaled.AnnualPUE || perf.AnnualWUE != unscaled.AnnualWUE {
+		t.Error("zero design values changed the figures")
+	}
+}
@@ -1,188 +1,396 @@
 package data
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 )
 
-// ReadAllDataCenters reads the “us_datacenters.csv” file
-func ReadAllDataCenters(filename string) ([]DataCenter, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+// ColumnKind says how a dataset column is parsed and validated.
+type ColumnKind int
 
-	reader := csv.NewReader(file)
-	// Optionally, allow lazy quotes if your CSV has some formatting issues:
-	reader.LazyQuotes = true
+const (
+	ColumnText ColumnKind = iota
+	ColumnName
+	ColumnLatitude
+	ColumnLongitude
+	ColumnLandPrice
+	ColumnElectricity
+	ColumnNotes
+)
 
-	// Read the header row
-	if _, err := reader.Read(); err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %v", err)
-	}
+// Column is one column of a dataset schema, matched to the CSV header by name.
+type Column struct {
+	Name     string
+	Kind     ColumnKind
+	Required bool
+	// Rest marks a column whose value runs over any extra fields, for the notes list in
+	// us_possible_locations.csv that is not quoted as one field.
+	Rest bool
+}
 
-	var dataCenters []DataCenter
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			fmt.Printf("Skipping record due to error: %v\n", err)
-			continue
-		}
+// Schema describes a CSV of sites.
+type Schema struct {
+	Name    string
+	Columns []Column
+	// Country, when set, is the ISO 3166-1 alpha-2 code given to every row. Outside the US
+	// it also turns off the check that coordinates fall in the United States.
+	Country string
+}
 
-		// Expecting at least three fields: latitude, longitude, location_name.
-		if len(record) < 3 {
-			fmt.Printf("Skipping record, not enough fields: %v\n", record)
-			continue
-		}
+// PossibleLocationsSchema describes “us_possible_locations.csv”.
+var PossibleLocationsSchema = Schema{
+	Name: "possible locations",
+	Columns: []Column{
+		{Name: "latitude", Kind: ColumnLatitude, Required: true},
+		{Name: "longitude", Kind: ColumnLongitude, Required: true},
+		{Name: "location name", Kind: ColumnName, Required: true},
+		{Name: "land price", Kind: ColumnLandPrice, Required: true},
+		{Name: "electricity", Kind: ColumnElectricity, Required: true},
+		{Name: "notes", Kind: ColumnNotes, Rest: true},
+	},
+}
 
-		lat, err := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
-		if err != nil {
-			fmt.Printf("Skipping record, invalid latitude: %v\n", err)
-			continue
-		}
-		lng, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
-		if err != nil {
-			fmt.Printf("Skipping record, invalid longitude: %v\n", err)
-			continue
-		}
-		name := strings.TrimSpace(record[2])
+// ExistingDatacentersSchema describes “us_datacenters.csv”.
+var ExistingDatacentersSchema = Schema{
+	Name: "existing datacenters",
+	Columns: []Column{
+		{Name: "latitude", Kind: ColumnLatitude, Required: true},
+		{Name: "longitude", Kind: ColumnLongitude, Required: true},
+		{Name: "location name", Kind: ColumnName, Required: true},
+		{Name: "land price", Kind: ColumnLandPrice},
+		{Name: "electricity", Kind: ColumnElectricity},
+		{Name: "notes", Kind: ColumnNotes},
+		{Name: "source", Kind: ColumnText},
+	},
+}
 
-		dataCenters = append(dataCenters, DataCenter{
-			Name:      name,
-			Latitude:  lat,
-			Longitude: lng,
-		})
-	}
-	return dataCenters, nil
+// Severity of a Diagnostic. Rows with an error in a coordinate or the name are left out
+// of the loaded dataset; other errors keep the row.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in one row of a dataset.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   string   `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
 }
 
-// parseDataCenterLine splits the line so last two fields are lat/lng
-func parseDataCenterLine(line string) (*DataCenter, error) {
-	parts := strings.Split(line, ",")
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("line has fewer than 3 fields")
+func (d Diagnostic) String() string {
+	col := ""
+	if d.Column != "" {
+		col = d.Column + ": "
 	}
-	longitudeStr := parts[len(parts)-1]
-	latitudeStr := parts[len(parts)-2]
-	name := strings.Join(parts[:len(parts)-2], ",")
+	return fmt.Sprintf("%s:%d: %s: %s%s", d.File, d.Line, d.Severity, col, d.Message)
+}
 
-	lat, err := strconv.ParseFloat(latitudeStr, 64)
-	if err != nil {
-		return nil, err
-	}
-	lng, err := strconv.ParseFloat(longitudeStr, 64)
-	if err != nil {
-		return nil, err
+// Dataset is a loaded CSV of sites and the problems found in it.
+type Dataset struct {
+	Rows        []DatacenterLocation `json:"rows"`
+	Diagnostics []Diagnostic         `json:"diagnostics"`
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func (d Dataset) HasErrors() bool {
+	for _, diag := range d.Diagnostics {
+		if diag.Severity == SeverityError {
+			return true
+		}
 	}
-	return &DataCenter{Name: name, Latitude: lat, Longitude: lng}, nil
+	return false
 }
 
-// ReadDatacenterLocations reads “us_possible_locations.csv”
-func ReadDatacenterLocations(filename string) ([]DatacenterLocation, error) {
-	file, err := os.Open(filename)
+// US bounding box, including Alaska, Hawaii and the territories, used to flag likely
+// swapped or mistyped coordinates. Guam and the Northern Mariana Islands lie west of the
+// antimeridian, in their own longitude band.
+const (
+	usMinLatitude         = 13
+	usMaxLatitude         = 72
+	usMinLongitude        = -180
+	usMaxLongitude        = -64
+	usPacificMinLongitude = 144
+	usPacificMaxLongitude = 146
+)
+
+// LoadDataset reads filename against schema. Errors are only returned when the file or its
+// header cannot be read; everything row-level is reported in Dataset.Diagnostics.
+func LoadDataset(filename string, schema Schema) (Dataset, error) {
+	content, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+		return Dataset{}, fmt.Errorf("failed to open %s CSV: %w", schema.Name, err)
 	}
-	defer file.Close()
+	// The raw lines let a Rest column keep the text the lazy-quote parser split up.
+	lines := bytes.Split(content, []byte("\n"))
 
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(bytes.NewReader(content))
 	reader.LazyQuotes = true
 	reader.FieldsPerRecord = -1
 
-	// skip header
-	_, err = reader.Read()
+	header, err := reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		return Dataset{}, fmt.Errorf("failed to read CSV header: %w", err)
 	}
+	index, err := schema.columnIndex(header)
+	if err != nil {
+		return Dataset{}, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	rest := schema.restIndex(index)
+
+	var ds Dataset
+	report := func(line int, column string, severity Severity, format string, args ...interface{}) {
+		ds.Diagnostics = append(ds.Diagnostics, Diagnostic{
+			File: filename, Line: line, Column: column, Severity: severity, Message: fmt.Sprintf(format, args...),
+		})
+	}
+	sites := newSiteSet("line")
 
-	var locations []DatacenterLocation
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			return nil, fmt.Errorf("error reading CSV record: %w", err)
+		line, _ := reader.FieldPos(0)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report(parseErr.StartLine, "", SeverityError, "%v", parseErr.Err)
+			continue
+		} else if err != nil {
+			return ds, fmt.Errorf("error reading CSV record: %w", err)
 		}
-		if len(record) < 6 {
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
 			continue
 		}
 
-		lat, err := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid latitude value: %s", record[0])
+		if rest >= 0 && len(record) > rest+1 {
+			record = append(record[:rest:rest], rawField(lines, reader, rest))
+		}
+
+		rowReport := func(column string, severity Severity, format string, args ...interface{}) {
+			report(line, column, severity, format, args...)
 		}
-		lng, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid longitude value: %s", record[1])
+		loc, ok := schema.parseRow(record, index, rowReport)
+		if ok && sites.add(&loc, line, rowReport) {
+			ds.Rows = append(ds.Rows, loc)
 		}
+	}
+	return ds, nil
+}
 
-		locations = append(locations, DatacenterLocation{
-			Latitude:    lat,
-			Longitude:   lng,
-			Name:        strings.TrimSpace(record[2]),
-			LandPrice:   strings.TrimSpace(record[3]),
-			Electricity: strings.TrimSpace(record[4]),
-			Notes:       parseNotes(strings.TrimSpace(record[5])),
-		})
+// restIndex returns the record index of the schema's Rest column, or -1 if it has none.
+func (s Schema) restIndex(index []int) int {
+	for i, col := range s.Columns {
+		if col.Rest && index[i] >= 0 {
+			return index[i]
+		}
 	}
-	return locations, nil
+	return -1
 }
 
-// ReadExistingDatacenters reads “us_datacenters.csv” again to produce a []DatacenterLocation
-func ReadExistingDatacenters(filename string) ([]DatacenterLocation, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open existing datacenters CSV: %w", err)
+// rawField returns the text of the current record from field j to the end of its line,
+// without the quotes around it. In us_possible_locations.csv the notes list, quoted as
+// "{notes:["a","b"]}", is split by its inner quotes and commas, losing which commas
+// separate items.
+func rawField(lines [][]byte, reader *csv.Reader, j int) string {
+	line, column := reader.FieldPos(j)
+	text := strings.TrimSpace(string(lines[line-1][column-1:]))
+	if len(text) >= 2 && strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) {
+		text = text[1 : len(text)-1]
 	}
-	defer file.Close()
+	return text
+}
 
-	scanner := bufio.NewScanner(file)
-	var existingDCs []DatacenterLocation
+// reportFunc records a diagnostic against the row being read.
+type reportFunc func(column string, severity Severity, format string, args ...interface{})
 
-	// skip header
-	scanner.Scan()
+// ForCountry returns a copy of the schema for a dataset of sites in country.
+func (s Schema) ForCountry(country string) Schema {
+	s.Country = strings.ToUpper(country)
+	return s
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+// inUS reports whether the schema's sites are expected to be in the United States.
+func (s Schema) inUS() bool {
+	return s.Country == "" || s.Country == usCountryCode
+}
+
+// checkCoordinate reports a latitude or longitude that is off the globe, returning false,
+// or, when inUS is set, outside the United States.
+func checkCoordinate(column string, v float64, longitude, inUS bool, report reportFunc) bool {
+	limit, usMin, usMax := 90.0, float64(usMinLatitude), float64(usMaxLatitude)
+	if longitude {
+		limit, usMin, usMax = 180, usMinLongitude, usMaxLongitude
+	}
+	switch {
+	case math.IsNaN(v) || v < -limit || v > limit:
+		report(column, SeverityError, "%g is outside ±%g", v, limit)
+		return false
+	case !inUS:
+	case longitude && v >= usPacificMinLongitude && v <= usPacificMaxLongitude:
+	case v < usMin || v > usMax:
+		report(column, SeverityWarning, "%g is outside the United States", v)
+	}
+	return true
+}
+
+// siteSet remembers the sites read so far to catch repeats.
+type siteSet struct {
+	unit string // what a position counts, "line" or "feature"
+	seen map[[2]float64][]seenSite
+}
+
+type seenSite struct {
+	pos  int
+	name string
+}
+
+func newSiteSet(unit string) *siteSet {
+	return &siteSet{unit: unit, seen: make(map[[2]float64][]seenSite)}
+}
+
+// add records the site at pos. A site with the same coordinates and name as an earlier one
+// is an error and add returns false; one that only shares coordinates is a warning.
+func (s *siteSet) add(loc *DatacenterLocation, pos int, report reportFunc) bool {
+	key := [2]float64{loc.Latitude, loc.Longitude}
+	for _, prev := range s.seen[key] {
+		if strings.EqualFold(prev.name, loc.Name) {
+			report("", SeverityError, "duplicate of %s %d (%s)", s.unit, prev.pos, loc.Name)
+			return false
 		}
-		parts := strings.Split(line, ",")
-		if len(parts) < 3 {
-			continue
+	}
+	if len(s.seen[key]) > 0 {
+		first := s.seen[key][0]
+		report("", SeverityWarning, "same coordinates as %s %d (%s)", s.unit, first.pos, first.name)
+	}
+	s.seen[key] = append(s.seen[key], seenSite{pos, loc.Name})
+	return true
+}
+
+// columnIndex finds each schema column in the header, ignoring case and treating
+// underscores as spaces.
+func (s Schema) columnIndex(header []string) ([]int, error) {
+	normalise := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", " "))
+	}
+	index := make([]int, len(s.Columns))
+	for i, col := range s.Columns {
+		index[i] = -1
+		for j, h := range header {
+			if normalise(h) == col.Name {
+				index[i] = j
+				break
+			}
+		}
+		if index[i] < 0 && col.Required {
+			return nil, fmt.Errorf("missing column %q", col.Name)
 		}
+	}
+	return index, nil
+}
 
-		longitudeStr := parts[len(parts)-1]
-		latitudeStr := parts[len(parts)-2]
-		name := strings.Join(parts[:len(parts)-2], ",")
+// parseRow converts one record, reporting problems through report. ok is false when the row
+// cannot be placed on the map.
+func (s Schema) parseRow(record []string, index []int, report reportFunc) (loc DatacenterLocation, ok bool) {
+	ok = true
+	width := len(index)
+	for i, col := range s.Columns {
+		if col.Rest && index[i] >= 0 {
+			width = -1
+		}
+	}
+	if width > 0 && len(record) > width {
+		report("", SeverityError, "%d fields, expected %d; an unquoted comma may have split a value", len(record), width)
+	}
 
-		lat, err := strconv.ParseFloat(latitudeStr, 64)
-		if err != nil {
-			continue
+	for i, col := range s.Columns {
+		value := ""
+		if j := index[i]; j >= 0 && j < len(record) {
+			value = strings.TrimSpace(record[j])
+			if col.Rest {
+				value = strings.TrimSpace(strings.Join(record[j:], ","))
+			}
 		}
-		lng, err := strconv.ParseFloat(longitudeStr, 64)
-		if err != nil {
+		if value == "" {
+			if col.Required {
+				report(col.Name, SeverityError, "missing value")
+				if col.Kind == ColumnLatitude || col.Kind == ColumnLongitude || col.Kind == ColumnName {
+					ok = false
+				}
+			}
 			continue
 		}
 
-		existingDCs = append(existingDCs, DatacenterLocation{
-			Name: name, Latitude: lat, Longitude: lng,
-		})
+		switch col.Kind {
+		case ColumnLatitude, ColumnLongitude:
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				report(col.Name, SeverityError, "invalid number %q", value)
+				ok = false
+			} else if !checkCoordinate(col.Name, v, col.Kind == ColumnLongitude, s.inUS(), report) {
+				ok = false
+			}
+			if col.Kind == ColumnLatitude {
+				loc.Latitude = v
+			} else {
+				loc.Longitude = v
+			}
+		case ColumnLandPrice:
+			loc.LandPrice = value
+			if _, err := loc.LandPricePerAcre(); err != nil {
+				report(col.Name, SeverityError, "%v", err)
+			}
+		case ColumnElectricity:
+			loc.Electricity = value
+			if _, err := loc.ElectricityPerKWh(); err != nil {
+				report(col.Name, SeverityError, "%v", err)
+			}
+		case ColumnNotes:
+			loc.Notes = value
+			loc.applyNotes(parseNotes(value))
+		case ColumnName:
+			loc.Name = value
+		}
+	}
+	if s.Country != "" {
+		loc.Country = s.Country
+	}
+	return loc, ok
+}
+
+// ReadAllDataCenters reads the “us_datacenters.csv” file
+func ReadAllDataCenters(filename string) ([]DataCenter, error) {
+	ds, err := LoadDataset(filename, ExistingDatacentersSchema)
+	if err != nil {
+		return nil, err
+	}
+	dataCenters := make([]DataCenter, 0, len(ds.Rows))
+	for _, loc := range ds.Rows {
+		dataCenters = append(dataCenters, DataCenter{Name: loc.Name, Latitude: loc.Latitude, Longitude: loc.Longitude})
 	}
-	return existingDCs, scanner.Err()
+	return dataCenters, nil
 }
 
-// parseNotes (simple pass-through, or do advanced logic)
-func parseNotes(notesStr string) string {
-	return notesStr
+// ReadDatacenterLocations reads “us_possible_locations.csv”
+func ReadDatacenterLocations(filename string) ([]DatacenterLocation, error) {
+	ds, err := LoadDataset(filename, PossibleLocationsSchema)
+	return ds.Rows, err
+}
+
+// ReadExistingDatacenters reads “us_datacenters.csv” again to produce a []DatacenterLocation
+func ReadExistingDatacenters(filename string) ([]DatacenterLocation, error) {
+	ds, err := LoadDataset(filename, ExistingDatacentersSchema)
+	return ds.Rows, err
 }
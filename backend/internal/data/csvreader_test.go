@@ -0,0 +1,120 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDatasetDiagnostics(t *testing.T) {
+	const (
+		possibleHeader = "latitude,longitude,location name,land price,electricity,notes\n"
+		existingHeader = "latitude,longitude,location_name,land_price,electricity,notes,source\n"
+		huntsville     = `34.7304,-86.5861,"Huntsville, AL","$75,000-150,000/acre","$0.0972/kWh","{notes:["Growing tech hub"]}"` + "\n"
+	)
+	tests := []struct {
+		name     string
+		schema   Schema
+		csv      string
+		rows     int
+		line     int
+		column   string
+		severity Severity
+		message  string
+	}{
+		{
+			name:   "short row",
+			schema: PossibleLocationsSchema,
+			csv:    possibleHeader + `34.7304,-86.5861,"Huntsville, AL","$75,000-150,000/acre"` + "\n",
+			rows:   1, line: 2, column: "electricity", severity: SeverityError, message: "missing value",
+		},
+		{
+			name:   "short row without a name",
+			schema: ExistingDatacentersSchema,
+			csv:    existingHeader + "39.0438,-77.4874\n",
+			rows:   0, line: 2, column: "location name", severity: SeverityError, message: "missing value",
+		},
+		{
+			name:   "unparseable latitude",
+			schema: PossibleLocationsSchema,
+			csv:    possibleHeader + huntsville + `34.7x,-86.5861,"Decatur, AL","$50,000/acre","$0.09/kWh",` + "\n",
+			rows:   1, line: 3, column: "latitude", severity: SeverityError, message: `invalid number "34.7x"`,
+		},
+		{
+			name:   "latitude off the globe",
+			schema: PossibleLocationsSchema,
+			csv:    possibleHeader + `134.7,-86.5861,"Decatur, AL","$50,000/acre","$0.09/kWh",` + "\n",
+			rows:   0, line: 2, column: "latitude", severity: SeverityError, message: "134.7 is outside ±90",
+		},
+		{
+			name:   "latitude outside the US",
+			schema: ExistingDatacentersSchema,
+			csv:    existingHeader + "9.0438,-77.4874,Equinix DC1 (Ashburn VA),,,,Equinix\n",
+			rows:   1, line: 2, column: "latitude", severity: SeverityWarning, message: "9.0438 is outside the United States",
+		},
+		{
+			name:   "unparseable price",
+			schema: PossibleLocationsSchema,
+			csv:    possibleHeader + `34.7304,-86.5861,"Huntsville, AL","call for price","$0.0972/kWh",` + "\n",
+			rows:   1, line: 2, column: "land price", severity: SeverityError, message: `cannot parse price "call for price"`,
+		},
+		{
+			name:   "unknown price unit",
+			schema: PossibleLocationsSchema,
+			csv:    possibleHeader + `34.7304,-86.5861,"Huntsville, AL","$75,000/acre","$0.0972/therm",` + "\n",
+			rows:   1, line: 2, column: "electricity", severity: SeverityError, message: "unknown price unit",
+		},
+		{
+			name:   "duplicate row",
+			schema: PossibleLocationsSchema,
+			csv:    possibleHeader + huntsville + huntsville,
+			rows:   1, line: 3, severity: SeverityError, message: "duplicate of line 2 (Huntsville, AL)",
+		},
+		{
+			name:   "shared coordinates",
+			schema: ExistingDatacentersSchema,
+			csv: existingHeader + "39.0438,-77.4874,AWS US East (Ashburn VA),,,,AWS\n" +
+				"39.0438,-77.4874,Equinix DC1 (Ashburn VA),,,,Equinix\n",
+			rows: 2, line: 3, severity: SeverityWarning, message: "same coordinates as line 2 (AWS US East (Ashburn VA))",
+		},
+		{
+			name:   "extra fields",
+			schema: ExistingDatacentersSchema,
+			csv:    existingHeader + "39.0438,-77.4874,Equinix DC1 (Ashburn VA),,,,Equinix,extra\n",
+			rows:   1, line: 2, severity: SeverityError, message: "8 fields, expected 7",
+		},
+	}
+	for _, tt := range tests {
+		filename := filepath.Join(t.TempDir(), "sites.csv")
+		if err := os.WriteFile(filename, []byte(tt.csv), 0644); err != nil {
+			t.Fatal(err)
+		}
+		ds, err := LoadDataset(filename, tt.schema)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(ds.Rows) != tt.rows {
+			t.Errorf("%s: loaded %d rows, want %d", tt.name, len(ds.Rows), tt.rows)
+		}
+		if len(ds.Diagnostics) != 1 {
+			t.Errorf("%s: got diagnostics %v, want one", tt.name, ds.Diagnostics)
+			continue
+		}
+		d := ds.Diagnostics[0]
+		if d.File != filename || d.Line != tt.line || d.Column != tt.column || d.Severity != tt.severity || !strings.Contains(d.Message, tt.message) {
+			t.Errorf("%s: got %s, want line %d %s: %s: %s", tt.name, d, tt.line, tt.severity, tt.column, tt.message)
+		}
+	}
+}
+
+func TestLoadDatasetMissingColumn(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sites.csv")
+	if err := os.WriteFile(filename, []byte("lat,lng,name\n1,2,x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDataset(filename, PossibleLocationsSchema); err == nil || !strings.Contains(err.Error(), `missing column "latitude"`) {
+		t.Errorf("got %v, want a missing column error", err)
+	}
+}
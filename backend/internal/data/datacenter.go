@@ -2,7 +2,10 @@ package data
 
 import (
 	"math"
+	"regexp"
 	"strings"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
 )
 
 // DataCenter is used for reading existing DC info from CSV (us_datacenters.csv)
@@ -20,15 +23,38 @@ type DatacenterLocation struct {
 	Electricity string  `json:"electricity,omitempty"`
 	Notes       string  `json:"notes,omitempty"`
 
+	// Tags are the individual notes; the attributes below are derived from them.
+	Tags               []string `json:"tags,omitempty"`
+	Hyperscalers       []string `json:"hyperscalers,omitempty"`
+	SubmarineCable     bool     `json:"submarine_cable,omitempty"`
+	GovernmentPresence bool     `json:"government_presence,omitempty"`
+
+	Facility *FacilityProfile `json:"facility,omitempty"`
+	// Schedule is when a planned site (a cart item) operates; nil means from now on.
+	Schedule *OperatingSchedule `json:"schedule,omitempty"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the site's country; empty means the US
+	// unless the coordinates resolve elsewhere. Subdivision is its ISO 3166-2 code.
+	Country     string `json:"country,omitempty"`
+	Subdivision string `json:"subdivision,omitempty"`
+
+	State      string `json:"state,omitempty"`
+	StateFIPS  string `json:"state_fips,omitempty"`
+	County     string `json:"county,omitempty"`
+	CountyFIPS string `json:"county_fips,omitempty"` // five-digit state+county code
+
 	EcoScore               int     `json:"eco_score,omitempty"`
 	CarbonImpact           float64 `json:"carbon_impact,omitempty"`
 	TempIncrease           float64 `json:"temp_increase,omitempty"`
 	WaterUsage             float64 `json:"water_usage,omitempty"`
 	RenewableAccess        int     `json:"renewable_access,omitempty"`
+	DisasterRisk           float64 `json:"disaster_risk,omitempty"`
 	DatacenterDensity      int     `json:"datacenter_density,omitempty"`
 	DensityImpactScore     int     `json:"density_impact_score,omitempty"`
 	CompoundedTempIncrease float64 `json:"compounded_temp_increase,omitempty"`
 	WaterCompetition       float64 `json:"water_competition,omitempty"`
+
+	Cooling *CoolingPerformance `json:"cooling,omitempty"`
 }
 
 // EnvironmentalData used for advanced impact calculation
@@ -42,91 +68,58 @@ type EnvironmentalData struct {
 	BiodiversitySensitivity float64
 	LandUseChangeImpact     float64
 	SocioeconomicImpact     float64
+	MonthlyClimate          []ClimateNormal
+
+	Place geo.Place
 }
 
-// GetEnvironmentalData aggregates the data needed for the advanced calculations
-func GetEnvironmentalData(loc *DatacenterLocation) EnvironmentalData {
-	state := extractStateCode(loc.Name)
+// GetEnvironmentalData aggregates the data needed for the advanced calculations from the
+// EnvironmentalProvider of the location's country. Density is counted from nearby, which
+// may be nil.
+func GetEnvironmentalData(loc *DatacenterLocation, nearby *SpatialIndex) EnvironmentalData {
+	place := ResolvePlace(loc)
+	p := EnvironmentalProviderFor(place.CountryCode)
 	return EnvironmentalData{
-		GridEmissionsIntensity:  getGridEmissionsIntensity(state),
-		RenewablePenetration:    getRenewablePenetration(state),
-		WaterScarcityIndex:      getWaterScarcityIndex(loc.Latitude, loc.Longitude),
-		AmbientTemperature:      getAverageTemperature(loc.Latitude, loc.Longitude),
-		DatacenterDensity:       countNearbyCenters(loc),
-		NaturalDisasterRisk:     getNaturalDisasterRisk(loc.Latitude, loc.Longitude),
-		BiodiversitySensitivity: getBiodiversitySensitivity(loc.Latitude, loc.Longitude),
-		LandUseChangeImpact:     getLandUseChangeImpact(loc.Latitude, loc.Longitude),
-		SocioeconomicImpact:     getSocioeconomicImpact(loc.Latitude, loc.Longitude),
+		GridEmissionsIntensity:  p.GridEmissionsIntensity(place),
+		RenewablePenetration:    p.RenewablePenetration(place),
+		WaterScarcityIndex:      p.WaterScarcityIndex(loc.Latitude, loc.Longitude),
+		AmbientTemperature:      p.AverageTemperature(loc.Latitude, loc.Longitude),
+		DatacenterDensity:       nearby.CountWithin(loc, DensityRadiusKm),
+		NaturalDisasterRisk:     p.NaturalDisasterRisk(loc.Latitude, loc.Longitude),
+		BiodiversitySensitivity: p.BiodiversitySensitivity(loc.Latitude, loc.Longitude),
+		LandUseChangeImpact:     p.LandUseChangeImpact(loc.Latitude, loc.Longitude),
+		SocioeconomicImpact:     p.SocioeconomicImpact(loc.Latitude, loc.Longitude),
+		MonthlyClimate:          p.MonthlyClimate(loc.Latitude, loc.Longitude),
+		Place:                   place,
 	}
 }
 
-// Below are the private “helper” functions that you had in your original code.
-// They are basically unchanged except for being package-private (lowercase first letter).
-
-func extractStateCode(name string) string {
-	parts := splitCommaSpace(name)
-	if len(parts) >= 2 {
-		st := parts[len(parts)-1]
-		if len(st) == 2 {
-			return st
+// ResolvePlace finds the country, state and county for a location from its coordinates.
+// A location with a non-US Country keeps that country, so sites near the border are not
+// scored as a US state. When the point is outside every boundary US sites fall back to the
+// state code in their name.
+func ResolvePlace(loc *DatacenterLocation) geo.Place {
+	if country := strings.ToUpper(loc.Country); country != "" && country != usCountryCode {
+		place := geo.DefaultResolver().ResolveIn(country, loc.Latitude, loc.Longitude)
+		if loc.Subdivision != "" {
+			place.SubdivisionCode = loc.Subdivision
 		}
+		return place
 	}
-	return "Unknown"
-}
-
-func splitCommaSpace(s string) []string {
-	return strings.Split(s, ", ")
-}
-
-// getGridEmissionsIntensity, getRenewablePenetration, etc.
-func getGridEmissionsIntensity(stateCode string) float64 {
-	gridData := map[string]float64{
-		// [snip] same map as before
-	}
-	if val, ok := gridData[stateCode]; ok {
-		return val
+	if place, ok := geo.DefaultResolver().Resolve(loc.Latitude, loc.Longitude); ok {
+		return place
 	}
-	return 0.45 // default
+	return geo.Place{StateCode: extractStateCode(loc.Name)}
 }
 
-func getRenewablePenetration(stateCode string) float64 {
-	// [snip] same map as before
-	return 20.1
-}
+// trailingStateCode matches names like "Huntsville, AL" and "Equinix DC1 (Ashburn VA)".
+var trailingStateCode = regexp.MustCompile(`(?:, |\(\w[\w .-]* )([A-Z]{2})\)?$`)
 
-func getWaterScarcityIndex(lat, lng float64) float64 {
-	// [snip] your logic
-	return 2.0
-}
-
-func getAverageTemperature(lat, lng float64) float64 {
-	// [snip]
-	return 25.0
-}
-
-func countNearbyCenters(loc *DatacenterLocation) int {
-	// [snip]
-	return 0
-}
-
-func getNaturalDisasterRisk(lat, lng float64) float64 {
-	// [snip]
-	return 0.3
-}
-
-func getBiodiversitySensitivity(lat, lng float64) float64 {
-	// [snip]
-	return 0.5
-}
-
-func getLandUseChangeImpact(lat, lng float64) float64 {
-	// [snip]
-	return 0.5
-}
-
-func getSocioeconomicImpact(lat, lng float64) float64 {
-	// [snip]
-	return 0.3
+func extractStateCode(name string) string {
+	if m := trailingStateCode.FindStringSubmatch(strings.TrimSpace(name)); m != nil {
+		return m[1]
+	}
+	return "Unknown"
 }
 
 // Basic Haversine
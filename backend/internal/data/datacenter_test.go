@@ -0,0 +1,65 @@
+package data
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// stateCodesInName finds the state codes a site name mentions, e.g. "VA" in
+// "Equinix DC1 (Ashburn VA)" and both codes in "Boardman, OR/WA".
+var stateCodesInName = regexp.MustCompile(`\b[A-Z]{2}\b`)
+
+func namedStates(name string) map[string]bool {
+	states := make(map[string]bool)
+	for _, code := range stateCodesInName.FindAllString(name, -1) {
+		states[code] = true
+	}
+	if strings.Contains(name, "Guam") {
+		states["GU"] = true
+	}
+	return states
+}
+
+func TestResolvePlaceMatchesSiteNames(t *testing.T) {
+	for _, filename := range []string{"../../us_datacenters.csv", "../../us_possible_locations.csv"} {
+		sites, err := ReadDatacenterLocations(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range sites {
+			want := namedStates(sites[i].Name)
+			if len(want) == 0 {
+				t.Errorf("%s: %q names no state", filename, sites[i].Name)
+				continue
+			}
+			place := ResolvePlace(&sites[i])
+			if !want[place.StateCode] {
+				t.Errorf("%s: %q at %.4f, %.4f resolved to %q", filename, sites[i].Name, sites[i].Latitude, sites[i].Longitude, place.StateCode)
+			}
+		}
+	}
+}
+
+func TestResolvePlaceKeepsNonUSCountry(t *testing.T) {
+	tests := []struct {
+		name     string
+		loc      DatacenterLocation
+		wantCode string
+		wantSub  string
+	}{
+		{"Vancouver", DatacenterLocation{Latitude: 49.2827, Longitude: -123.1207, Country: "CA"}, "CA", ""},
+		{"Windsor", DatacenterLocation{Latitude: 42.3149, Longitude: -83.0364, Country: "ca", Subdivision: "CA-ON"}, "CA", "CA-ON"},
+		{"Tijuana", DatacenterLocation{Latitude: 32.5149, Longitude: -117.0382, Country: "MX"}, "MX", ""},
+		{"Seattle", DatacenterLocation{Latitude: 47.6062, Longitude: -122.3321, Country: "US"}, "US", "US-WA"},
+	}
+	for _, tt := range tests {
+		place := ResolvePlace(&tt.loc)
+		if place.CountryCode != tt.wantCode || place.SubdivisionCode != tt.wantSub {
+			t.Errorf("%s: got %s %q, want %s %q", tt.name, place.CountryCode, place.SubdivisionCode, tt.wantCode, tt.wantSub)
+		}
+		if tt.wantCode != "US" && place.StateCode != "" {
+			t.Errorf("%s: resolved to US state %s", tt.name, place.StateCode)
+		}
+	}
+}
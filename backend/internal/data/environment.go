@@ -0,0 +1,496 @@
+package data
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
+)
+
+// defaultTables holds the versioned lookup tables shipped with the binary.
+//
+//go:embed tables/*.json
+var defaultTables embed.FS
+
+// EnvironmentalProvider supplies the regional inputs used by GetEnvironmentalData.
+// Implementations can be swapped with SetEnvironmentalProvider, or per country with
+// SetRegionProvider.
+type EnvironmentalProvider interface {
+	GridEmissionsIntensity(place geo.Place) float64   // kg CO2e/kWh
+	RenewablePenetration(place geo.Place) float64     // percent of generation (0-100)
+	WaterScarcityIndex(lat, lng float64) float64      // 0-5, higher is more scarce
+	AverageTemperature(lat, lng float64) float64      // annual mean °C
+	NaturalDisasterRisk(lat, lng float64) float64     // 0-1
+	BiodiversitySensitivity(lat, lng float64) float64 // 0-1
+	LandUseChangeImpact(lat, lng float64) float64     // 0-1
+	SocioeconomicImpact(lat, lng float64) float64     // 0-1
+	MonthlyClimate(lat, lng float64) []ClimateNormal  // January..December, °C
+}
+
+var (
+	defaultTableProvider                       = mustLoadTableProvider(defaultTables, "tables")
+	provider             EnvironmentalProvider = defaultTableProvider
+	providerMu           sync.RWMutex
+
+	// regionProviders maps an ISO country code to the provider for sites in that country.
+	regionProviders = make(map[string]EnvironmentalProvider)
+)
+
+// SetEnvironmentalProvider replaces the provider used by GetEnvironmentalData.
+func SetEnvironmentalProvider(p EnvironmentalProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	provider = p
+}
+
+// CurrentEnvironmentalProvider returns the provider used by GetEnvironmentalData.
+func CurrentEnvironmentalProvider() EnvironmentalProvider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return provider
+}
+
+// SetRegionProvider sets the provider used for sites in country, an ISO 3166-1 alpha-2
+// code, in place of the national indicators.
+func SetRegionProvider(country string, p EnvironmentalProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	regionProviders[strings.ToUpper(country)] = p
+}
+
+// EnvironmentalProviderFor returns the provider for sites in country. The US, and sites
+// whose country is unknown, use the current provider; other countries use their region
+// provider if one is set, and otherwise the national indicators of the default tables.
+func EnvironmentalProviderFor(country string) EnvironmentalProvider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	country = strings.ToUpper(country)
+	if p, ok := regionProviders[country]; ok {
+		return p
+	}
+	if country == "" || country == usCountryCode {
+		return provider
+	}
+	base, ok := provider.(*TableProvider)
+	if !ok || base.national == nil {
+		base = defaultTableProvider
+	}
+	return &NationalProvider{Country: country, base: base}
+}
+
+// TablesVersion identifies the tables behind EnvironmentalProviderFor(country): the manifest
+// version of a table provider, or the provider's type for others.
+func TablesVersion(country string) string {
+	switch p := EnvironmentalProviderFor(country).(type) {
+	case *TableProvider:
+		return p.Manifest.Version
+	case *NationalProvider:
+		return "national/" + p.base.Manifest.Version
+	default:
+		return fmt.Sprintf("%T", p)
+	}
+}
+
+// LoadTableProviderDir loads a table provider from a directory containing a manifest.json,
+// laid out like internal/data/tables.
+func LoadTableProviderDir(dir string) (*TableProvider, error) {
+	return LoadTableProvider(os.DirFS(dir), ".")
+}
+
+// Manifest lists the table file used for each environmental input.
+type Manifest struct {
+	Version string            `json:"version"`
+	Tables  map[string]string `json:"tables"`
+}
+
+const usCountryCode = "US"
+
+// StateTable maps a two-letter state code to a value (eGRID, EIA).
+type StateTable struct {
+	Version string             `json:"version"`
+	Source  string             `json:"source"`
+	Unit    string             `json:"unit"`
+	Default float64            `json:"default"`
+	Values  map[string]float64 `json:"values"`
+}
+
+// Zone is a circular area of influence around a point.
+type Zone struct {
+	Name     string  `json:"name"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	RadiusKm float64 `json:"radius_km"`
+	Value    float64 `json:"value"`
+}
+
+// Region is a lat/lng box. Missing bounds are open; present bounds are exclusive.
+type Region struct {
+	Name   string   `json:"name"`
+	MinLat *float64 `json:"min_lat,omitempty"`
+	MaxLat *float64 `json:"max_lat,omitempty"`
+	MinLng *float64 `json:"min_lng,omitempty"`
+	MaxLng *float64 `json:"max_lng,omitempty"`
+	Value  float64  `json:"value"`
+}
+
+func (r Region) contains(lat, lng float64) bool {
+	return (r.MinLat == nil || lat > *r.MinLat) &&
+		(r.MaxLat == nil || lat < *r.MaxLat) &&
+		(r.MinLng == nil || lng > *r.MinLng) &&
+		(r.MaxLng == nil || lng < *r.MaxLng)
+}
+
+// ZoneTable resolves a coordinate by checking zones, then the urban value (if set and the
+// point is in an urban area), then regions in order, then the default.
+type ZoneTable struct {
+	Version string   `json:"version"`
+	Source  string   `json:"source"`
+	Unit    string   `json:"unit"`
+	Combine string   `json:"combine,omitempty"` // "first" (default) or "max" across matching zones
+	Zones   []Zone   `json:"zones,omitempty"`
+	Urban   *float64 `json:"urban,omitempty"`
+	Regions []Region `json:"regions,omitempty"`
+	Default float64  `json:"default"`
+}
+
+// TemperatureTable models annual mean temperature as a latitude gradient with regional offsets.
+type TemperatureTable struct {
+	Version      string   `json:"version"`
+	Source       string   `json:"source"`
+	Unit         string   `json:"unit"`
+	Base         float64  `json:"base"`
+	ReferenceLat float64  `json:"reference_lat"`
+	LatGradient  float64  `json:"lat_gradient"`
+	Adjustments  []Region `json:"adjustments"`
+}
+
+// ClimateNormal is the mean dry-bulb and wet-bulb temperature for one month.
+type ClimateNormal struct {
+	Month   int     `json:"month"`
+	DryBulb float64 `json:"dry_bulb"`
+	WetBulb float64 `json:"wet_bulb"`
+}
+
+// ClimateNormalsTable models monthly normals as a cosine seasonal cycle around the annual
+// mean, whose amplitude grows with latitude, and a regional wet-bulb depression.
+type ClimateNormalsTable struct {
+	Version               string   `json:"version"`
+	Source                string   `json:"source"`
+	Unit                  string   `json:"unit"`
+	PeakMonth             int      `json:"peak_month"`
+	ReferenceLat          float64  `json:"reference_lat"`
+	AmplitudeBase         float64  `json:"amplitude_base"`
+	AmplitudePerLatDegree float64  `json:"amplitude_per_lat_degree"`
+	AmplitudeAdjustments  []Region `json:"amplitude_adjustments"`
+	WetBulbDepression     struct {
+		Default float64  `json:"default"`
+		Regions []Region `json:"regions"`
+	} `json:"wet_bulb_depression"`
+}
+
+// Indicators are the national or subdivision values of a NationalIndicatorsTable entry.
+// Zero fields are not set.
+type Indicators struct {
+	Name                 string  `json:"name,omitempty"`
+	GridIntensity        float64 `json:"grid_intensity,omitempty"`
+	RenewablePenetration float64 `json:"renewable_penetration,omitempty"`
+	WaterStress          float64 `json:"water_stress,omitempty"`
+}
+
+// NationalIndicatorsTable holds country-level values (Ember, Aqueduct), keyed by ISO 3166-1
+// alpha-2 code, with ISO 3166-2 subdivision overrides where the grid differs by province.
+type NationalIndicatorsTable struct {
+	Version      string                `json:"version"`
+	Source       string                `json:"source"`
+	Units        map[string]string     `json:"units"`
+	Default      Indicators            `json:"default"`
+	Countries    map[string]Indicators `json:"countries"`
+	Subdivisions map[string]Indicators `json:"subdivisions"`
+}
+
+// lookup returns the first non-zero field of the subdivision, the country and the default.
+func (t *NationalIndicatorsTable) lookup(place geo.Place, country string, field func(Indicators) float64) float64 {
+	if v := field(t.Subdivisions[place.SubdivisionCode]); v != 0 {
+		return v
+	}
+	if v := field(t.Countries[country]); v != 0 {
+		return v
+	}
+	return field(t.Default)
+}
+
+// TableProvider is the default EnvironmentalProvider backed by versioned data tables.
+type TableProvider struct {
+	Manifest Manifest
+
+	grid        StateTable
+	renewables  StateTable
+	water       ZoneTable
+	temperature TemperatureTable
+	urban       ZoneTable
+	disaster    ZoneTable
+	biodiv      ZoneTable
+	landUse     ZoneTable
+	socio       ZoneTable
+	climate     ClimateNormalsTable
+	national    *NationalIndicatorsTable // optional
+	// trajectories are optional too; GridTrajectories falls back to the embedded ones.
+	trajectories *GridTrajectoryTable
+}
+
+// LoadTableProvider reads manifest.json under dir in fsys and every table it references.
+func LoadTableProvider(fsys fs.FS, dir string) (*TableProvider, error) {
+	p := &TableProvider{}
+	if err := readJSON(fsys, path.Join(dir, "manifest.json"), &p.Manifest); err != nil {
+		return nil, err
+	}
+
+	targets := map[string]interface{}{
+		"grid_intensity":        &p.grid,
+		"renewable_penetration": &p.renewables,
+		"water_scarcity":        &p.water,
+		"temperature":           &p.temperature,
+		"urban_areas":           &p.urban,
+		"disaster_risk":         &p.disaster,
+		"biodiversity":          &p.biodiv,
+		"land_use":              &p.landUse,
+		"socioeconomic":         &p.socio,
+		"climate_normals":       &p.climate,
+	}
+	for key, target := range targets {
+		file, ok := p.Manifest.Tables[key]
+		if !ok {
+			return nil, fmt.Errorf("manifest is missing table %q", key)
+		}
+		if err := readJSON(fsys, path.Join(dir, file), target); err != nil {
+			return nil, err
+		}
+	}
+	// National indicators are optional so table sets for a single country still load.
+	if file, ok := p.Manifest.Tables["national_indicators"]; ok {
+		p.national = &NationalIndicatorsTable{}
+		if err := readJSON(fsys, path.Join(dir, file), p.national); err != nil {
+			return nil, err
+		}
+	}
+	if file, ok := p.Manifest.Tables["grid_trajectories"]; ok {
+		p.trajectories = &GridTrajectoryTable{}
+		if err := readJSON(fsys, path.Join(dir, file), p.trajectories); err != nil {
+			return nil, err
+		}
+		if err := p.trajectories.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return p, nil
+}
+
+func mustLoadTableProvider(fsys fs.FS, dir string) *TableProvider {
+	p, err := LoadTableProvider(fsys, dir)
+	if err != nil {
+		panic(fmt.Sprintf("loading embedded environmental tables: %v", err))
+	}
+	return p
+}
+
+func readJSON(fsys fs.FS, name string, v interface{}) error {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func (t StateTable) lookup(stateCode string) float64 {
+	if val, ok := t.Values[stateCode]; ok {
+		return val
+	}
+	return t.Default
+}
+
+func (p *TableProvider) lookupZones(t ZoneTable, lat, lng float64) float64 {
+	matched := false
+	best := 0.0
+	for _, z := range t.Zones {
+		if distance(lat, lng, z.Lat, z.Lng) > z.RadiusKm {
+			continue
+		}
+		if t.Combine != "max" {
+			return z.Value
+		}
+		if !matched || z.Value > best {
+			best = z.Value
+		}
+		matched = true
+	}
+	if matched {
+		return best
+	}
+	if t.Urban != nil && p.inUrbanArea(lat, lng) {
+		return *t.Urban
+	}
+	for _, r := range t.Regions {
+		if r.contains(lat, lng) {
+			return r.Value
+		}
+	}
+	return t.Default
+}
+
+func (p *TableProvider) inUrbanArea(lat, lng float64) bool {
+	for _, z := range p.urban.Zones {
+		if distance(lat, lng, z.Lat, z.Lng) <= z.RadiusKm {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TableProvider) GridEmissionsIntensity(place geo.Place) float64 {
+	return p.grid.lookup(place.StateCode)
+}
+
+func (p *TableProvider) RenewablePenetration(place geo.Place) float64 {
+	return p.renewables.lookup(place.StateCode)
+}
+
+func (p *TableProvider) WaterScarcityIndex(lat, lng float64) float64 {
+	return p.lookupZones(p.water, lat, lng)
+}
+
+func (p *TableProvider) AverageTemperature(lat, lng float64) float64 {
+	return p.temperature.at(lat, lng, true)
+}
+
+// at is the latitude-gradient temperature, with the regional adjustments when adjust is set.
+func (t TemperatureTable) at(lat, lng float64, adjust bool) float64 {
+	temp := t.Base - t.LatGradient*math.Abs(lat-t.ReferenceLat)
+	if !adjust {
+		return temp
+	}
+	for _, adj := range t.Adjustments {
+		if adj.contains(lat, lng) {
+			temp += adj.Value
+			break
+		}
+	}
+	return temp
+}
+
+func (p *TableProvider) NaturalDisasterRisk(lat, lng float64) float64 {
+	return p.lookupZones(p.disaster, lat, lng)
+}
+
+func (p *TableProvider) BiodiversitySensitivity(lat, lng float64) float64 {
+	return p.lookupZones(p.biodiv, lat, lng)
+}
+
+func (p *TableProvider) LandUseChangeImpact(lat, lng float64) float64 {
+	return p.lookupZones(p.landUse, lat, lng)
+}
+
+func (p *TableProvider) SocioeconomicImpact(lat, lng float64) float64 {
+	return p.lookupZones(p.socio, lat, lng)
+}
+
+func (p *TableProvider) MonthlyClimate(lat, lng float64) []ClimateNormal {
+	return p.climate.normals(p.AverageTemperature(lat, lng), lat, lng, true)
+}
+
+// normals builds the seasonal cycle around annual, with the regional amplitude and
+// wet-bulb adjustments when adjust is set.
+func (t ClimateNormalsTable) normals(annual, lat, lng float64, adjust bool) []ClimateNormal {
+	amplitude := t.AmplitudeBase + t.AmplitudePerLatDegree*math.Max(0, math.Abs(lat)-t.ReferenceLat)
+	depression := t.WetBulbDepression.Default
+	if adjust {
+		for _, adj := range t.AmplitudeAdjustments {
+			if adj.contains(lat, lng) {
+				amplitude += adj.Value
+				break
+			}
+		}
+		for _, r := range t.WetBulbDepression.Regions {
+			if r.contains(lat, lng) {
+				depression = r.Value
+				break
+			}
+		}
+	}
+	amplitude = math.Max(0, amplitude)
+
+	peak := t.PeakMonth
+	if lat < 0 {
+		peak += 6
+	}
+	normals := make([]ClimateNormal, 12)
+	for i := range normals {
+		month := i + 1
+		dry := annual + amplitude*math.Cos(2*math.Pi*float64(month-peak)/12)
+		// Air holds less moisture in the cold, so the depression shrinks in winter.
+		dep := math.Max(0.5, depression*(1+0.03*(dry-annual)))
+		normals[i] = ClimateNormal{Month: month, DryBulb: dry, WetBulb: dry - dep}
+	}
+	return normals
+}
+
+// NationalProvider is the EnvironmentalProvider for a country without its own table set.
+// Grid, renewable and water values come from the national indicators; temperature and
+// climate use the latitude models without the US regional adjustments; the zone-based
+// inputs use their table defaults.
+type NationalProvider struct {
+	Country string
+	base    *TableProvider
+}
+
+func (p *NationalProvider) indicator(place geo.Place, field func(Indicators) float64) float64 {
+	if p.base.national == nil {
+		return 0
+	}
+	return p.base.national.lookup(place, p.Country, field)
+}
+
+func (p *NationalProvider) GridEmissionsIntensity(place geo.Place) float64 {
+	return p.indicator(place, func(i Indicators) float64 { return i.GridIntensity })
+}
+
+func (p *NationalProvider) RenewablePenetration(place geo.Place) float64 {
+	return p.indicator(place, func(i Indicators) float64 { return i.RenewablePenetration })
+}
+
+func (p *NationalProvider) WaterScarcityIndex(lat, lng float64) float64 {
+	return p.indicator(geo.Place{}, func(i Indicators) float64 { return i.WaterStress })
+}
+
+func (p *NationalProvider) AverageTemperature(lat, lng float64) float64 {
+	return p.base.temperature.at(lat, lng, false)
+}
+
+func (p *NationalProvider) NaturalDisasterRisk(lat, lng float64) float64 {
+	return p.base.disaster.Default
+}
+
+func (p *NationalProvider) BiodiversitySensitivity(lat, lng float64) float64 {
+	return p.base.biodiv.Default
+}
+
+func (p *NationalProvider) LandUseChangeImpact(lat, lng float64) float64 {
+	return p.base.landUse.Default
+}
+
+func (p *NationalProvider) SocioeconomicImpact(lat, lng float64) float64 {
+	return p.base.socio.Default
+}
+
+func (p *NationalProvider) MonthlyClimate(lat, lng float64) []ClimateNormal {
+	return p.base.climate.normals(p.AverageTemperature(lat, lng), lat, lng, false)
+}
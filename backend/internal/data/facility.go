@@ -0,0 +1,204 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BackupGeneration is the fuel used by a facility's backup power.
+type BackupGeneration string
+
+const (
+	BackupDiesel  BackupGeneration = "diesel"
+	BackupGas     BackupGeneration = "gas"
+	BackupBattery BackupGeneration = "battery"
+)
+
+// Facility tiers sold in the frontend.
+const (
+	TierStandard = "standard"
+	TierEco      = "eco"
+	TierNextGen  = "next-gen"
+)
+
+// FacilityProfile describes the facility built on a site. Zero fields are
+// filled in from the Standard tier by WithDefaults. BackupHoursPerYear and
+// OnsiteRenewableShare are pointers because 0 is a meaningful value for them;
+// only nil is filled in.
+type FacilityProfile struct {
+	Tier                  string            `json:"tier,omitempty"`
+	ITLoadMW              float64           `json:"it_load_mw,omitempty"`
+	Cooling               CoolingTechnology `json:"cooling,omitempty"`
+	WUE                   float64           `json:"wue,omitempty"`        // litres per kWh of IT energy; 0 derives it from the cooling model
+	DesignPUE             float64           `json:"design_pue,omitempty"` // 0 derives PUE from the cooling model
+	LandFootprintHectares float64           `json:"land_footprint_hectares,omitempty"`
+	BackupGeneration      BackupGeneration  `json:"backup_generation,omitempty"`
+	BackupHoursPerYear    *float64          `json:"backup_hours_per_year,omitempty"`
+	OnsiteRenewableShare  *float64          `json:"onsite_renewable_share,omitempty"` // 0-1 of total energy
+	BuildCost             float64           `json:"build_cost,omitempty"`             // dollars, excluding land
+}
+
+var facilityTiers = map[string]FacilityProfile{
+	TierStandard: {
+		Tier:                  TierStandard,
+		ITLoadMW:              15,
+		Cooling:               CoolingChilledWater,
+		LandFootprintHectares: 12,
+		BackupGeneration:      BackupDiesel,
+		BackupHoursPerYear:    float64Ptr(backupHours[BackupDiesel]),
+		OnsiteRenewableShare:  float64Ptr(0),
+		BuildCost:             2000000,
+	},
+	TierEco: {
+		Tier:                  TierEco,
+		ITLoadMW:              15,
+		Cooling:               CoolingAir,
+		LandFootprintHectares: 10,
+		BackupGeneration:      BackupGas,
+		BackupHoursPerYear:    float64Ptr(backupHours[BackupGas]),
+		OnsiteRenewableShare:  float64Ptr(0.3),
+		BuildCost:             3500000,
+	},
+	TierNextGen: {
+		Tier:                  TierNextGen,
+		ITLoadMW:              15,
+		Cooling:               CoolingLiquid,
+		LandFootprintHectares: 8,
+		BackupGeneration:      BackupBattery,
+		BackupHoursPerYear:    float64Ptr(backupHours[BackupBattery]),
+		OnsiteRenewableShare:  float64Ptr(0.7),
+		BuildCost:             5000000,
+	},
+}
+
+// backupEmissions is kg CO2e per kWh generated by backup power.
+var backupEmissions = map[BackupGeneration]float64{
+	BackupDiesel:  0.75,
+	BackupGas:     0.45,
+	BackupBattery: 0,
+}
+
+// backupHours is the yearly running time assumed for each kind of backup power:
+// generators are exercised and cover outages, batteries only cover outages.
+var backupHours = map[BackupGeneration]float64{
+	BackupDiesel:  50,
+	BackupGas:     30,
+	BackupBattery: 0,
+}
+
+// Upper bounds on facility parameters. They are far beyond any real facility and keep the
+// model's results finite.
+const (
+	maxFacilityITLoadMW  = 10000 // 10 GW
+	maxFacilityWUE       = 20    // litres per kWh
+	maxFacilityPUE       = 5
+	maxFacilityHectares  = 100000
+	maxFacilityBuildCost = 1e12 // dollars
+	hoursPerYear         = 8760
+)
+
+func float64Ptr(v float64) *float64 { return &v }
+
+// StandardFacility is the profile assumed when a caller does not supply one.
+func StandardFacility() FacilityProfile {
+	return facilityTiers[TierStandard]
+}
+
+// FacilityForTier returns the preset profile for a tier name such as
+// "Standard", "Eco" or "Next-Gen". Matching ignores case.
+func FacilityForTier(tier string) (FacilityProfile, bool) {
+	p, ok := facilityTiers[strings.ToLower(strings.TrimSpace(tier))]
+	return p, ok
+}
+
+// WithDefaults fills zero fields from the profile's tier, or from the Standard
+// tier when it has none, and checks the result. Unset backup hours default to
+// those of the backup generation in use rather than the tier's.
+func (p FacilityProfile) WithDefaults() (FacilityProfile, error) {
+	base := StandardFacility()
+	if p.Tier != "" {
+		t, ok := FacilityForTier(p.Tier)
+		if !ok {
+			return p, fmt.Errorf("unknown facility tier %q", p.Tier)
+		}
+		base = t
+	}
+
+	if p.ITLoadMW == 0 {
+		p.ITLoadMW = base.ITLoadMW
+	}
+	if p.Cooling == "" {
+		p.Cooling = base.Cooling
+	}
+	if p.WUE == 0 {
+		p.WUE = base.WUE
+	}
+	if p.DesignPUE == 0 {
+		p.DesignPUE = base.DesignPUE
+	}
+	if p.LandFootprintHectares == 0 {
+		p.LandFootprintHectares = base.LandFootprintHectares
+	}
+	if p.BackupGeneration == "" {
+		p.BackupGeneration = base.BackupGeneration
+	}
+	if p.BackupHoursPerYear == nil {
+		p.BackupHoursPerYear = float64Ptr(backupHours[p.BackupGeneration])
+	}
+	if p.OnsiteRenewableShare == nil {
+		p.OnsiteRenewableShare = base.OnsiteRenewableShare
+	}
+	if p.BuildCost == 0 {
+		p.BuildCost = base.BuildCost
+	}
+	p.Tier = base.Tier
+
+	// The negated comparisons reject NaN as well as values out of range.
+	for _, f := range []struct {
+		name     string
+		value    float64
+		min, max float64
+	}{
+		{"it_load_mw", p.ITLoadMW, 0, maxFacilityITLoadMW},
+		{"wue", p.WUE, 0, maxFacilityWUE},
+		{"land_footprint_hectares", p.LandFootprintHectares, 0, maxFacilityHectares},
+		{"backup_hours_per_year", p.BackupHours(), 0, hoursPerYear},
+		{"onsite_renewable_share", p.RenewableShare(), 0, 1},
+		{"build_cost", p.BuildCost, 0, maxFacilityBuildCost},
+	} {
+		if !(f.value >= f.min && f.value <= f.max) {
+			return p, fmt.Errorf("%s must be between %g and %g, got %g", f.name, f.min, f.max, f.value)
+		}
+	}
+	if p.DesignPUE != 0 && !(p.DesignPUE >= 1 && p.DesignPUE <= maxFacilityPUE) {
+		return p, fmt.Errorf("design_pue must be 0 (derived) or between 1 and %g, got %g", float64(maxFacilityPUE), p.DesignPUE)
+	}
+	if _, ok := coolingModels[p.Cooling]; !ok {
+		return p, fmt.Errorf("unknown cooling technology %q", p.Cooling)
+	}
+	if _, ok := backupEmissions[p.BackupGeneration]; !ok {
+		return p, fmt.Errorf("unknown backup generation %q", p.BackupGeneration)
+	}
+	return p, nil
+}
+
+// BackupHours is BackupHoursPerYear, or 0 when it is unset.
+func (p FacilityProfile) BackupHours() float64 {
+	if p.BackupHoursPerYear == nil {
+		return 0
+	}
+	return *p.BackupHoursPerYear
+}
+
+// RenewableShare is OnsiteRenewableShare, or 0 when it is unset.
+func (p FacilityProfile) RenewableShare() float64 {
+	if p.OnsiteRenewableShare == nil {
+		return 0
+	}
+	return *p.OnsiteRenewableShare
+}
+
+// BackupEmissionsIntensity is kg CO2e per kWh from the backup generators.
+func (p FacilityProfile) BackupEmissionsIntensity() float64 {
+	return backupEmissions[p.BackupGeneration]
+}
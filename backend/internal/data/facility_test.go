@@ -0,0 +1,75 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFacilityForTier(t *testing.T) {
+	for _, tier := range []string{"Standard", "eco", " Next-Gen "} {
+		p, ok := FacilityForTier(tier)
+		if !ok {
+			t.Errorf("tier %q not found", tier)
+			continue
+		}
+		if _, err := p.WithDefaults(); err != nil {
+			t.Errorf("tier %q: %v", tier, err)
+		}
+	}
+	if _, ok := FacilityForTier("premium"); ok {
+		t.Error("unknown tier found")
+	}
+}
+
+func TestFacilityWithDefaults(t *testing.T) {
+	p, err := FacilityProfile{Tier: "eco", ITLoadMW: 40}.WithDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eco, _ := FacilityForTier(TierEco)
+	if p.ITLoadMW != 40 || p.Cooling != eco.Cooling || p.RenewableShare() != eco.RenewableShare() || p.BuildCost != eco.BuildCost {
+		t.Errorf("eco with 40 MW = %+v", p)
+	}
+
+	// Explicit zeros are kept; unset backup hours follow the backup generation in use.
+	p, err = FacilityProfile{BackupHoursPerYear: float64Ptr(0), OnsiteRenewableShare: float64Ptr(0)}.WithDefaults()
+	if err != nil || p.BackupHours() != 0 || p.RenewableShare() != 0 {
+		t.Errorf("explicit zeros: %+v, %v", p, err)
+	}
+	p, err = FacilityProfile{BackupGeneration: BackupGas}.WithDefaults()
+	if err != nil || p.BackupHours() != backupHours[BackupGas] || p.Tier != TierStandard {
+		t.Errorf("gas backup: %+v, %v", p, err)
+	}
+}
+
+func TestFacilityWithDefaultsRejects(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	tests := []struct {
+		name string
+		p    FacilityProfile
+	}{
+		{"unknown tier", FacilityProfile{Tier: "premium"}},
+		{"negative load", FacilityProfile{ITLoadMW: -1}},
+		{"NaN load", FacilityProfile{ITLoadMW: nan}},
+		{"infinite load", FacilityProfile{ITLoadMW: inf}},
+		{"huge load", FacilityProfile{ITLoadMW: 1e308}},
+		{"NaN WUE", FacilityProfile{WUE: nan}},
+		{"huge WUE", FacilityProfile{WUE: 1e6}},
+		{"PUE below 1", FacilityProfile{DesignPUE: 0.9}},
+		{"NaN PUE", FacilityProfile{DesignPUE: nan}},
+		{"huge PUE", FacilityProfile{DesignPUE: 100}},
+		{"infinite footprint", FacilityProfile{LandFootprintHectares: -inf}},
+		{"backup hours past a year", FacilityProfile{BackupHoursPerYear: float64Ptr(9000)}},
+		{"NaN backup hours", FacilityProfile{BackupHoursPerYear: float64Ptr(nan)}},
+		{"renewable share above 1", FacilityProfile{OnsiteRenewableShare: float64Ptr(1.5)}},
+		{"NaN renewable share", FacilityProfile{OnsiteRenewableShare: float64Ptr(nan)}},
+		{"NaN build cost", FacilityProfile{BuildCost: nan}},
+		{"unknown cooling", FacilityProfile{Cooling: "seawater"}},
+		{"unknown backup", FacilityProfile{BackupGeneration: "nuclear"}},
+	}
+	for _, tt := range tests {
+		if _, err := tt.p.WithDefaults(); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
+)
+
+// TrajectoryPoint is a grid intensity target as a share of the base-year intensity.
+type TrajectoryPoint struct {
+	Year   int     `json:"year"`
+	Factor float64 `json:"factor"`
+}
+
+// GridTrajectory is the decarbonisation path of one grid under its clean electricity policy.
+type GridTrajectory struct {
+	Policy  string            `json:"policy,omitempty"`
+	Targets []TrajectoryPoint `json:"targets"`
+}
+
+// GridTrajectoryTable holds grid decarbonisation trajectories by US state and, for sites
+// elsewhere, by ISO 3166-1 alpha-2 country code.
+type GridTrajectoryTable struct {
+	Version   string                    `json:"version"`
+	Source    string                    `json:"source"`
+	Unit      string                    `json:"unit"`
+	BaseYear  int                       `json:"base_year"` // year of the grid intensity tables
+	Default   GridTrajectory            `json:"default"`
+	States    map[string]GridTrajectory `json:"states"`
+	Countries map[string]GridTrajectory `json:"countries"`
+}
+
+func (t *GridTrajectoryTable) validate() error {
+	if err := t.validateTrajectory("default", t.Default); err != nil {
+		return err
+	}
+	// States and countries share codes (CA, DE), so each is checked under its own table.
+	for code, tr := range t.States {
+		if err := t.validateTrajectory("state "+code, tr); err != nil {
+			return err
+		}
+	}
+	for code, tr := range t.Countries {
+		if err := t.validateTrajectory("country "+code, tr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *GridTrajectoryTable) validateTrajectory(name string, tr GridTrajectory) error {
+	sort.Slice(tr.Targets, func(i, j int) bool { return tr.Targets[i].Year < tr.Targets[j].Year })
+	for i, p := range tr.Targets {
+		switch {
+		case p.Year <= t.BaseYear:
+			return fmt.Errorf("grid trajectory %s: target year %d is not after the base year %d", name, p.Year, t.BaseYear)
+		case p.Factor < 0:
+			return fmt.Errorf("grid trajectory %s: factor for %d is negative", name, p.Year)
+		case i > 0 && p.Year == tr.Targets[i-1].Year:
+			return fmt.Errorf("grid trajectory %s lists %d twice", name, p.Year)
+		}
+	}
+	return nil
+}
+
+// For returns the trajectory of the grid serving place: its state's for US sites, its
+// country's elsewhere, or the default.
+func (t *GridTrajectoryTable) For(place geo.Place) GridTrajectory {
+	var (
+		tr GridTrajectory
+		ok bool
+	)
+	if place.CountryCode == "" || place.CountryCode == usCountryCode {
+		tr, ok = t.States[place.StateCode]
+	} else {
+		tr, ok = t.Countries[strings.ToUpper(place.CountryCode)]
+	}
+	if !ok {
+		return t.Default
+	}
+	return tr
+}
+
+// Factor returns the intensity of the grid serving place in year as a share of the
+// base-year intensity, interpolating linearly from 1 at the base year through the
+// targets and holding the last target after it.
+func (t *GridTrajectoryTable) Factor(place geo.Place, year int) float64 {
+	prevYear, prev := t.BaseYear, 1.0
+	if year <= prevYear {
+		return prev
+	}
+	for _, p := range t.For(place).Targets {
+		if year <= p.Year {
+			f := float64(year-prevYear) / float64(p.Year-prevYear)
+			return prev + f*(p.Factor-prev)
+		}
+		prevYear, prev = p.Year, p.Factor
+	}
+	return prev
+}
+
+// GridTrajectories returns the trajectories of the current provider's tables, or of the
+// default tables when it has none.
+func GridTrajectories() *GridTrajectoryTable {
+	if p, ok := CurrentEnvironmentalProvider().(*TableProvider); ok && p.trajectories != nil {
+		return p.trajectories
+	}
+	return defaultTableProvider.trajectories
+}
@@ -0,0 +1,201 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
+)
+
+// ImportFormat is a file format ImportSites can read.
+type ImportFormat string
+
+const (
+	FormatGeoJSON   ImportFormat = "geojson"
+	FormatKML       ImportFormat = "kml"
+	FormatKMZ       ImportFormat = "kmz"
+	FormatShapefile ImportFormat = "shapefile" // a zip of the .shp and .dbf
+)
+
+// DetectImportFormat guesses the format from a file name's extension.
+func DetectImportFormat(filename string) (ImportFormat, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".geojson", ".json":
+		return FormatGeoJSON, true
+	case ".kml":
+		return FormatKML, true
+	case ".kmz":
+		return FormatKMZ, true
+	case ".zip", ".shz":
+		return FormatShapefile, true
+	}
+	return "", false
+}
+
+// ColumnMapping names the source attribute read into each DatacenterLocation field.
+// Attribute names match case-insensitively; empty fields use DefaultColumnMapping.
+type ColumnMapping struct {
+	Name        string `json:"name,omitempty"`
+	LandPrice   string `json:"land_price,omitempty"`
+	Electricity string `json:"electricity,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+}
+
+// DefaultColumnMapping reads attributes named after the DatacenterLocation JSON fields.
+// KML placemarks expose their <name> and <description> as the name and description attributes.
+var DefaultColumnMapping = ColumnMapping{
+	Name:        "name",
+	LandPrice:   "land_price",
+	Electricity: "electricity",
+	Notes:       "notes",
+}
+
+func (m ColumnMapping) withDefaults() ColumnMapping {
+	d := DefaultColumnMapping
+	if m.Name == "" {
+		m.Name = d.Name
+	}
+	if m.LandPrice == "" {
+		m.LandPrice = d.LandPrice
+	}
+	if m.Electricity == "" {
+		m.Electricity = d.Electricity
+	}
+	if m.Notes == "" {
+		m.Notes = d.Notes
+	}
+	return m
+}
+
+// importedFeature is a point read from an import file with its attributes.
+type importedFeature struct {
+	lat, lng   float64
+	hasPoint   bool
+	attributes map[string]string
+}
+
+// attribute looks a value up by name, ignoring case.
+func (f importedFeature) attribute(name string) string {
+	if v, ok := f.attributes[name]; ok {
+		return strings.TrimSpace(v)
+	}
+	for k, v := range f.attributes {
+		if strings.EqualFold(k, name) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// ImportSites reads the point features of an uploaded file into a Dataset, mapping their
+// attributes to location fields. country is the ISO code of the sites' region, or "" for
+// the US. Diagnostic lines are 1-based feature numbers. An error is only returned when the
+// file as a whole cannot be read.
+func ImportSites(filename string, format ImportFormat, content []byte, mapping ColumnMapping, country string) (Dataset, error) {
+	var features []importedFeature
+	var err error
+	switch format {
+	case FormatGeoJSON:
+		features, err = readGeoJSONFeatures(content)
+	case FormatKML:
+		features, err = readKMLFeatures(content)
+	case FormatKMZ:
+		features, err = readKMZFeatures(content)
+	case FormatShapefile:
+		features, err = readShapefileFeatures(content)
+	default:
+		return Dataset{}, fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	mapping = mapping.withDefaults()
+	country = strings.ToUpper(country)
+	inUS := country == "" || country == usCountryCode
+	var ds Dataset
+	sites := newSiteSet("feature")
+	for i, f := range features {
+		pos := i + 1
+		report := func(column string, severity Severity, format string, args ...interface{}) {
+			ds.Diagnostics = append(ds.Diagnostics, Diagnostic{
+				File: filename, Line: pos, Column: column, Severity: severity, Message: fmt.Sprintf(format, args...),
+			})
+		}
+		if !f.hasPoint {
+			report("", SeverityError, "feature has no point geometry")
+			continue
+		}
+		if !checkCoordinate("latitude", f.lat, false, inUS, report) || !checkCoordinate("longitude", f.lng, true, inUS, report) {
+			continue
+		}
+
+		loc := DatacenterLocation{
+			Latitude:    f.lat,
+			Longitude:   f.lng,
+			Name:        f.attribute(mapping.Name),
+			LandPrice:   f.attribute(mapping.LandPrice),
+			Electricity: f.attribute(mapping.Electricity),
+			Country:     country,
+		}
+		if loc.Name == "" {
+			loc.Name = fmt.Sprintf("Imported site %d", pos)
+			report(mapping.Name, SeverityWarning, "missing value, named %q", loc.Name)
+		}
+		if loc.LandPrice != "" {
+			if _, err := loc.LandPricePerAcre(); err != nil {
+				report(mapping.LandPrice, SeverityError, "%v", err)
+			}
+		}
+		if loc.Electricity != "" {
+			if _, err := loc.ElectricityPerKWh(); err != nil {
+				report(mapping.Electricity, SeverityError, "%v", err)
+			}
+		}
+		if notes := f.attribute(mapping.Notes); notes != "" {
+			loc.Notes = notes
+			loc.applyNotes(parseNotes(notes))
+		}
+
+		if sites.add(&loc, pos, report) {
+			ds.Rows = append(ds.Rows, loc)
+		}
+	}
+	return ds, nil
+}
+
+// readGeoJSONFeatures reads a FeatureCollection, or a single Feature, of Point features.
+func readGeoJSONFeatures(content []byte) ([]importedFeature, error) {
+	var fc geo.FeatureCollection
+	if err := json.Unmarshal(content, &fc); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	switch fc.Type {
+	case "FeatureCollection":
+	case "Feature":
+		var f geo.Feature
+		if err := json.Unmarshal(content, &f); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+		}
+		fc.Features = []geo.Feature{f}
+	default:
+		return nil, fmt.Errorf("GeoJSON is a %q, not a FeatureCollection", fc.Type)
+	}
+
+	features := make([]importedFeature, len(fc.Features))
+	for i, f := range fc.Features {
+		attrs := make(map[string]string, len(f.Properties))
+		for k, v := range f.Properties {
+			if v != nil {
+				attrs[k] = fmt.Sprint(v)
+			}
+		}
+		features[i].attributes = attrs
+		if lat, lng, err := f.Geometry.Point(); err == nil {
+			features[i].lat, features[i].lng, features[i].hasPoint = lat, lng, true
+		}
+	}
+	return features, nil
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// kmlPlacemark is the part of a KML <Placemark> read by the importer. Tags match any
+// namespace, so KML 2.1, 2.2 and Google's gx extensions all decode.
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Point       *struct {
+		Coordinates string `xml:"coordinates"`
+	} `xml:"Point"`
+	MultiPoint []struct {
+		Coordinates string `xml:"coordinates"`
+	} `xml:"MultiGeometry>Point"`
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"ExtendedData>SchemaData>SimpleData"`
+}
+
+// readKMLFeatures reads every Placemark in the document, however deeply it sits in
+// Folders. Placemarks without a Point, or a MultiGeometry holding one, have no geometry.
+func readKMLFeatures(content []byte) ([]importedFeature, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	var features []importedFeature
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		var p kmlPlacemark
+		if err := dec.DecodeElement(&p, &start); err != nil {
+			return nil, fmt.Errorf("invalid KML placemark: %w", err)
+		}
+
+		f := importedFeature{attributes: map[string]string{
+			"name":        strings.TrimSpace(p.Name),
+			"description": strings.TrimSpace(p.Description),
+		}}
+		for _, d := range p.Data {
+			f.attributes[d.Name] = d.Value
+		}
+		for _, d := range p.SimpleData {
+			f.attributes[d.Name] = d.Value
+		}
+		coords := ""
+		if p.Point != nil {
+			coords = p.Point.Coordinates
+		} else if len(p.MultiPoint) > 0 {
+			coords = p.MultiPoint[0].Coordinates
+		}
+		if lng, lat, ok := parseKMLCoordinates(coords); ok {
+			f.lat, f.lng, f.hasPoint = lat, lng, true
+		}
+		features = append(features, f)
+	}
+	if features == nil {
+		return nil, fmt.Errorf("KML has no placemarks")
+	}
+	return features, nil
+}
+
+// parseKMLCoordinates reads the first "lng,lat[,alt]" tuple.
+func parseKMLCoordinates(s string) (lng, lat float64, ok bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, 0, false
+	}
+	parts := strings.Split(fields[0], ",")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	lng, err1 := strconv.ParseFloat(parts[0], 64)
+	lat, err2 := strconv.ParseFloat(parts[1], 64)
+	return lng, lat, err1 == nil && err2 == nil
+}
+
+// readKMZFeatures reads the KML inside a KMZ archive: doc.kml when present, as Google Earth
+// does, otherwise the first .kml file.
+func readKMZFeatures(content []byte) ([]importedFeature, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMZ: %w", err)
+	}
+	var kml *zip.File
+	for _, f := range zr.File {
+		if strings.EqualFold(path.Ext(f.Name), ".kml") && (kml == nil || path.Base(f.Name) == "doc.kml") {
+			kml = f
+		}
+	}
+	if kml == nil {
+		return nil, fmt.Errorf("KMZ has no .kml file")
+	}
+	doc, err := readZipFile(kml)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s from KMZ: %w", kml.Name, err)
+	}
+	return readKMLFeatures(doc)
+}
+
+// maxImportEntrySize bounds how much a single file inside an uploaded archive may expand to.
+const maxImportEntrySize = 64 << 20
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(io.LimitReader(rc, maxImportEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxImportEntrySize {
+		return nil, fmt.Errorf("%s is larger than %d MB", f.Name, maxImportEntrySize>>20)
+	}
+	return content, nil
+}
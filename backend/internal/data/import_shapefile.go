@@ -0,0 +1,164 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+)
+
+// ESRI shape types read by the importer; PointZ and PointM start with the same x and y.
+const (
+	shapeNull   = 0
+	shapePoint  = 1
+	shapePointZ = 11
+	shapePointM = 21
+)
+
+// readShapefileFeatures reads a point layer from a zip holding one .shp and its .dbf.
+// Coordinates are taken as WGS84 longitude and latitude; the .prj is not read.
+func readShapefileFeatures(content []byte) ([]importedFeature, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("shapefiles must be uploaded as a zip: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".shp" && ext != ".dbf" {
+			continue
+		}
+		if _, dup := files[ext]; dup {
+			return nil, fmt.Errorf("zip holds more than one %s file", ext)
+		}
+		files[ext] = f
+	}
+	if files[".shp"] == nil || files[".dbf"] == nil {
+		return nil, fmt.Errorf("zip must hold a .shp and a .dbf file")
+	}
+
+	shp, err := readZipFile(files[".shp"])
+	if err != nil {
+		return nil, err
+	}
+	dbf, err := readZipFile(files[".dbf"])
+	if err != nil {
+		return nil, err
+	}
+	points, err := readShpPoints(shp)
+	if err != nil {
+		return nil, err
+	}
+	records, err := readDBFRecords(dbf)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != len(points) {
+		return nil, fmt.Errorf(".shp has %d shapes but .dbf has %d records", len(points), len(records))
+	}
+
+	features := make([]importedFeature, len(points))
+	for i, p := range points {
+		features[i] = importedFeature{lat: p.lat, lng: p.lng, hasPoint: p.ok, attributes: records[i]}
+	}
+	return features, nil
+}
+
+type shpPoint struct {
+	lat, lng float64
+	ok       bool // false for null shapes
+}
+
+// readShpPoints reads the records of a .shp file of Point, PointZ or PointM shapes.
+func readShpPoints(b []byte) ([]shpPoint, error) {
+	const headerSize = 100
+	if len(b) < headerSize || binary.BigEndian.Uint32(b[0:4]) != 9994 {
+		return nil, fmt.Errorf("not a .shp file")
+	}
+	switch t := binary.LittleEndian.Uint32(b[32:36]); t {
+	case shapeNull, shapePoint, shapePointZ, shapePointM:
+	default:
+		return nil, fmt.Errorf("shapefile has shape type %d; only point layers can be imported", t)
+	}
+
+	var points []shpPoint
+	for off := headerSize; off+8 <= len(b); {
+		length := int(binary.BigEndian.Uint32(b[off+4:off+8])) * 2 // in 16-bit words
+		content := b[off+8:]
+		if length < 4 || length > len(content) {
+			return nil, fmt.Errorf("truncated .shp record %d", len(points)+1)
+		}
+		content = content[:length]
+		switch binary.LittleEndian.Uint32(content[0:4]) {
+		case shapeNull:
+			points = append(points, shpPoint{})
+		case shapePoint, shapePointZ, shapePointM:
+			if length < 20 {
+				return nil, fmt.Errorf("truncated .shp record %d", len(points)+1)
+			}
+			points = append(points, shpPoint{
+				lng: math.Float64frombits(binary.LittleEndian.Uint64(content[4:12])),
+				lat: math.Float64frombits(binary.LittleEndian.Uint64(content[12:20])),
+				ok:  true,
+			})
+		default:
+			return nil, fmt.Errorf(".shp record %d is not a point", len(points)+1)
+		}
+		off += 8 + length
+	}
+	return points, nil
+}
+
+// readDBFRecords reads a dBase III table, keeping deleted records so positions line up
+// with the .shp. Values are trimmed strings keyed by field name.
+func readDBFRecords(b []byte) ([]map[string]string, error) {
+	if len(b) < 32 {
+		return nil, fmt.Errorf("not a .dbf file")
+	}
+	count := int(binary.LittleEndian.Uint32(b[4:8]))
+	headerLen := int(binary.LittleEndian.Uint16(b[8:10]))
+	recordLen := int(binary.LittleEndian.Uint16(b[10:12]))
+	if headerLen > len(b) || recordLen < 1 {
+		return nil, fmt.Errorf("corrupt .dbf header")
+	}
+	// The record count comes from the upload, so check it against the file before
+	// allocating for it.
+	if count > (len(b)-headerLen)/recordLen {
+		return nil, fmt.Errorf(".dbf header claims %d records but the file holds at most %d", count, (len(b)-headerLen)/recordLen)
+	}
+
+	type field struct {
+		name   string
+		offset int
+		length int
+	}
+	var fields []field
+	offset := 1 // after the deletion flag
+	for pos := 32; pos+32 <= headerLen && b[pos] != 0x0D; pos += 32 {
+		name := b[pos : pos+11]
+		if end := bytes.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		length := int(b[pos+16])
+		fields = append(fields, field{strings.TrimSpace(string(name)), offset, length})
+		offset += length
+	}
+	if offset > recordLen {
+		return nil, fmt.Errorf("corrupt .dbf field descriptors")
+	}
+
+	records := make([]map[string]string, 0, count)
+	for i := 0; i < count; i++ {
+		start := headerLen + i*recordLen
+		rec := b[start : start+recordLen]
+		values := make(map[string]string, len(fields))
+		for _, f := range fields {
+			values[f.name] = strings.TrimSpace(string(rec[f.offset : f.offset+f.length]))
+		}
+		records = append(records, values)
+	}
+	return records, nil
+}
@@ -0,0 +1,248 @@
+package data
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+const testKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Folder>
+      <Placemark>
+        <name>Site A</name>
+        <ExtendedData><Data name="land_price"><value>$10,000 - $20,000</value></Data></ExtendedData>
+        <Point><coordinates>-77.5,39.0,0</coordinates></Point>
+      </Placemark>
+    </Folder>
+    <Placemark>
+      <name>Site B</name>
+      <MultiGeometry><Point><coordinates>-96.8,32.8</coordinates></Point></MultiGeometry>
+    </Placemark>
+    <Placemark><name>No geometry</name></Placemark>
+  </Document>
+</kml>`
+
+func TestReadKMLFeatures(t *testing.T) {
+	features, err := readKMLFeatures([]byte(testKML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name     string
+		lat, lng float64
+		hasPoint bool
+	}{
+		{"Site A", 39.0, -77.5, true},
+		{"Site B", 32.8, -96.8, true},
+		{"No geometry", 0, 0, false},
+	}
+	if len(features) != len(want) {
+		t.Fatalf("got %d features, want %d", len(features), len(want))
+	}
+	for i, w := range want {
+		f := features[i]
+		if f.attribute("name") != w.name || f.hasPoint != w.hasPoint || f.lat != w.lat || f.lng != w.lng {
+			t.Errorf("feature %d = %+v, want %+v", i, f, w)
+		}
+	}
+	if got := features[0].attribute("land_price"); got != "$10,000 - $20,000" {
+		t.Errorf("land_price = %q", got)
+	}
+}
+
+func TestReadKMLFeaturesErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"empty":         "",
+		"no placemarks": `<kml><Document></Document></kml>`,
+		"truncated":     testKML[:len(testKML)/2],
+	} {
+		if _, err := readKMLFeatures([]byte(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func zipFiles(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadKMZFeatures(t *testing.T) {
+	other := `<kml><Placemark><name>Other</name><Point><coordinates>1,2</coordinates></Point></Placemark></kml>`
+	kmz := zipFiles(t, map[string][]byte{"other.kml": []byte(other), "files/doc.kml": []byte(testKML)})
+	features, err := readKMZFeatures(kmz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 3 || features[0].attribute("name") != "Site A" {
+		t.Errorf("expected doc.kml to be read, got %+v", features)
+	}
+
+	for name, content := range map[string][]byte{
+		"not a zip": []byte("PK not really"),
+		"no kml":    zipFiles(t, map[string][]byte{"readme.txt": []byte("hi")}),
+	} {
+		if _, err := readKMZFeatures(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// shpFile builds a .shp of point records; nil points are null shapes.
+func shpFile(shapeType uint32, points [][2]float64, null []bool) []byte {
+	var body bytes.Buffer
+	for i, p := range points {
+		var rec bytes.Buffer
+		if null != nil && null[i] {
+			binary.Write(&rec, binary.LittleEndian, uint32(shapeNull))
+		} else {
+			binary.Write(&rec, binary.LittleEndian, uint32(shapePoint))
+			binary.Write(&rec, binary.LittleEndian, p[0])
+			binary.Write(&rec, binary.LittleEndian, p[1])
+		}
+		binary.Write(&body, binary.BigEndian, uint32(i+1))
+		binary.Write(&body, binary.BigEndian, uint32(rec.Len()/2))
+		body.Write(rec.Bytes())
+	}
+	header := make([]byte, 100)
+	binary.BigEndian.PutUint32(header[0:4], 9994)
+	binary.BigEndian.PutUint32(header[24:28], uint32((100+body.Len())/2))
+	binary.LittleEndian.PutUint32(header[28:32], 1000)
+	binary.LittleEndian.PutUint32(header[32:36], shapeType)
+	return append(header, body.Bytes()...)
+}
+
+// dbfFile builds a dBase III table of character fields.
+func dbfFile(fields []string, width int, records [][]string) []byte {
+	headerLen := 32 + 32*len(fields) + 1
+	recordLen := 1 + width*len(fields)
+	b := make([]byte, 32, headerLen+recordLen*len(records)+1)
+	b[0] = 0x03
+	binary.LittleEndian.PutUint32(b[4:8], uint32(len(records)))
+	binary.LittleEndian.PutUint16(b[8:10], uint16(headerLen))
+	binary.LittleEndian.PutUint16(b[10:12], uint16(recordLen))
+	for _, f := range fields {
+		desc := make([]byte, 32)
+		copy(desc, f)
+		desc[11] = 'C'
+		desc[16] = byte(width)
+		b = append(b, desc...)
+	}
+	b = append(b, 0x0D)
+	for _, r := range records {
+		b = append(b, ' ')
+		for _, v := range r {
+			b = append(b, []byte(v+strings.Repeat(" ", width-len(v)))...)
+		}
+	}
+	return append(b, 0x1A)
+}
+
+func TestReadShapefileFeatures(t *testing.T) {
+	shp := shpFile(shapePoint, [][2]float64{{-77.5, 39.0}, {0, 0}}, []bool{false, true})
+	dbf := dbfFile([]string{"NAME", "PRICE"}, 12, [][]string{{"Site A", "$1 - $2"}, {"Nowhere", ""}})
+	features, err := readShapefileFeatures(zipFiles(t, map[string][]byte{"sites.shp": shp, "sites.dbf": dbf, "sites.prj": nil}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 2 {
+		t.Fatalf("got %d features, want 2", len(features))
+	}
+	if f := features[0]; !f.hasPoint || f.lat != 39.0 || f.lng != -77.5 || f.attribute("NAME") != "Site A" || f.attribute("PRICE") != "$1 - $2" {
+		t.Errorf("feature 0 = %+v", f)
+	}
+	if f := features[1]; f.hasPoint || f.attribute("NAME") != "Nowhere" {
+		t.Errorf("feature 1 = %+v", f)
+	}
+}
+
+func TestReadShapefileFeaturesErrors(t *testing.T) {
+	shp := shpFile(shapePoint, [][2]float64{{1, 2}}, nil)
+	dbf := dbfFile([]string{"NAME"}, 8, [][]string{{"A"}})
+	tests := map[string][]byte{
+		"not a zip":        []byte("nope"),
+		"missing dbf":      zipFiles(t, map[string][]byte{"a.shp": shp}),
+		"two shp files":    zipFiles(t, map[string][]byte{"a.shp": shp, "b.SHP": shp, "a.dbf": dbf}),
+		"count mismatch":   zipFiles(t, map[string][]byte{"a.shp": shp, "a.dbf": dbfFile([]string{"NAME"}, 8, nil)}),
+		"polygon layer":    zipFiles(t, map[string][]byte{"a.shp": shpFile(5, nil, nil), "a.dbf": dbf}),
+		"truncated record": zipFiles(t, map[string][]byte{"a.shp": shp[:len(shp)-4], "a.dbf": dbf}),
+	}
+	for name, content := range tests {
+		if _, err := readShapefileFeatures(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestReadShpPointsHostileHeaders(t *testing.T) {
+	shp := shpFile(shapePoint, [][2]float64{{1, 2}}, nil)
+
+	bad := func(mutate func(b []byte) []byte) []byte {
+		return mutate(append([]byte(nil), shp...))
+	}
+	tests := map[string][]byte{
+		"short header": shp[:50],
+		"wrong magic":  bad(func(b []byte) []byte { b[3] = 0; return b }),
+		"huge record length": bad(func(b []byte) []byte {
+			binary.BigEndian.PutUint32(b[104:108], math.MaxUint32/2)
+			return b
+		}),
+		"record shorter than a point": bad(func(b []byte) []byte {
+			binary.BigEndian.PutUint32(b[104:108], 4)
+			return b
+		}),
+	}
+	for name, content := range tests {
+		if _, err := readShpPoints(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestReadDBFRecordsHostileHeaders(t *testing.T) {
+	dbf := dbfFile([]string{"NAME"}, 8, [][]string{{"A"}, {"B"}})
+	records, err := readDBFRecords(dbf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1]["NAME"] != "B" {
+		t.Fatalf("records = %v", records)
+	}
+
+	bad := func(mutate func(b []byte)) []byte {
+		b := append([]byte(nil), dbf...)
+		mutate(b)
+		return b
+	}
+	tests := map[string][]byte{
+		"short":              dbf[:20],
+		"billions of rows":   bad(func(b []byte) { binary.LittleEndian.PutUint32(b[4:8], math.MaxUint32) }),
+		"one row too many":   bad(func(b []byte) { binary.LittleEndian.PutUint32(b[4:8], 3) }),
+		"header past end":    bad(func(b []byte) { binary.LittleEndian.PutUint16(b[8:10], math.MaxUint16) }),
+		"zero record size":   bad(func(b []byte) { binary.LittleEndian.PutUint16(b[10:12], 0) }),
+		"fields past record": bad(func(b []byte) { b[32+16] = 200 }),
+		"truncated records":  dbf[:len(dbf)-6],
+	}
+	for name, content := range tests {
+		if _, err := readDBFRecords(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
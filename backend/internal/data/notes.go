@@ -0,0 +1,76 @@
+package data
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// hyperscalers maps each hyperscale operator to a pattern matching it in a note.
+var hyperscalers = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"Amazon", regexp.MustCompile(`(?i)\b(amazon|aws)\b`)},
+	{"Apple", regexp.MustCompile(`(?i)\bapple\b`)},
+	{"Google", regexp.MustCompile(`(?i)\bgoogle\b`)},
+	{"Meta", regexp.MustCompile(`(?i)\b(meta|facebook)\b`)},
+	{"Microsoft", regexp.MustCompile(`(?i)\b(microsoft|azure)\b`)},
+	{"Oracle", regexp.MustCompile(`(?i)\boracle\b`)},
+}
+
+var (
+	submarineCableNote = regexp.MustCompile(`(?i)\bsubmarine cables?\b`)
+	governmentNote     = regexp.MustCompile(`(?i)\b(government|federal|military|state capital)\b`)
+)
+
+// parseNotes reads the notes column, written as {notes:["Growing tech hub","..."]} or as a
+// bare ["..."] list, into its list of tags. Text that is not in that form is kept as a
+// single tag, commas and all.
+func parseNotes(notesStr string) []string {
+	s := strings.TrimSpace(notesStr)
+	if s == "" {
+		return nil
+	}
+	list := s
+	if inner, ok := strings.CutPrefix(s, "{"); ok && strings.HasSuffix(inner, "}") {
+		key, value, found := strings.Cut(strings.TrimSuffix(inner, "}"), ":")
+		if !found || strings.Trim(strings.TrimSpace(key), `"`) != "notes" {
+			return []string{s}
+		}
+		list = strings.TrimSpace(value)
+	}
+
+	var items []string
+	if !strings.HasPrefix(list, "[") || json.Unmarshal([]byte(list), &items) != nil {
+		return []string{s}
+	}
+	var tags []string
+	for _, item := range items {
+		if tag := strings.TrimSpace(item); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// applyNotes sets the location's tags and the attributes derived from them. Notes keeps
+// the text the tags were parsed from.
+func (loc *DatacenterLocation) applyNotes(tags []string) {
+	loc.Tags = tags
+	loc.Hyperscalers = nil
+	loc.SubmarineCable = false
+	loc.GovernmentPresence = false
+	for _, h := range hyperscalers {
+		for _, tag := range tags {
+			if h.pattern.MatchString(tag) {
+				loc.Hyperscalers = append(loc.Hyperscalers, h.name)
+				break
+			}
+		}
+	}
+	for _, tag := range tags {
+		loc.SubmarineCable = loc.SubmarineCable || submarineCableNote.MatchString(tag)
+		loc.GovernmentPresence = loc.GovernmentPresence || governmentNote.MatchString(tag)
+	}
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNotes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{`{notes:["Growing tech hub","Low land costs"]}`, []string{"Growing tech hub", "Low land costs"}},
+		{` { notes: [ "One" ] } `, []string{"One"}},
+		{`["Bare list","Second"]`, []string{"Bare list", "Second"}},
+		{`{notes:[]}`, nil},
+		{"", nil},
+		{"Network-dense carrier hotel", []string{"Network-dense carrier hotel"}},
+		{"One of Google's largest data centers over 1,000 acres", []string{"One of Google's largest data centers over 1,000 acres"}},
+		{`{notes:["Campus of 1,000 acres","Hydro power"]}`, []string{"Campus of 1,000 acres", "Hydro power"}},
+		{`{"notes":["Quoted key"]}`, []string{"Quoted key"}},
+		{`[unquoted, list]`, []string{"[unquoted, list]"}},
+		{`{tags:["Other key"]}`, []string{`{tags:["Other key"]}`}},
+	}
+	for _, tt := range tests {
+		if got := parseNotes(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNotes(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyNotes(t *testing.T) {
+	tests := []struct {
+		tags         []string
+		hyperscalers []string
+		cable, gov   bool
+	}{
+		{[]string{"AWS and Azure regions nearby", "Microsoft campus"}, []string{"Amazon", "Microsoft"}, false, false},
+		{[]string{"Facebook data center", "Submarine cable landing sites"}, []string{"Meta"}, true, false},
+		{[]string{"State capital", "Federal agencies"}, nil, false, true},
+		{[]string{"Pineapple farms", "Metadata services"}, nil, false, false},
+		{nil, nil, false, false},
+	}
+	for _, tt := range tests {
+		loc := DatacenterLocation{Hyperscalers: []string{"stale"}, SubmarineCable: true, GovernmentPresence: true}
+		loc.applyNotes(tt.tags)
+		if !reflect.DeepEqual(loc.Hyperscalers, tt.hyperscalers) || loc.SubmarineCable != tt.cable || loc.GovernmentPresence != tt.gov {
+			t.Errorf("applyNotes(%q) = %v, cable %v, government %v; want %v, %v, %v",
+				tt.tags, loc.Hyperscalers, loc.SubmarineCable, loc.GovernmentPresence, tt.hyperscalers, tt.cable, tt.gov)
+		}
+	}
+}
+
+func TestLoadDatasetNotes(t *testing.T) {
+	tests := []struct {
+		filename string
+		schema   Schema
+		site     string
+		notes    string
+		tags     []string
+	}{
+		{"../../us_possible_locations.csv", PossibleLocationsSchema, "Huntsville, AL",
+			`{notes:["Growing tech hub","Google data center presence","Favorable business climate"]}`,
+			[]string{"Growing tech hub", "Google data center presence", "Favorable business climate"}},
+		{"../../us_datacenters.csv", ExistingDatacentersSchema, "Google Council Bluffs (Council Bluffs IA)",
+			"One of Google's largest data centers over 1,000 acres",
+			[]string{"One of Google's largest data centers over 1,000 acres"}},
+	}
+	for _, tt := range tests {
+		ds, err := LoadDataset(tt.filename, tt.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, loc := range ds.Rows {
+			if loc.Name != tt.site {
+				continue
+			}
+			found = true
+			if loc.Notes != tt.notes || !reflect.DeepEqual(loc.Tags, tt.tags) {
+				t.Errorf("%s: notes %q, tags %q; want %q, %q", tt.site, loc.Notes, loc.Tags, tt.notes, tt.tags)
+			}
+		}
+		if !found {
+			t.Errorf("%s not found in %s", tt.site, tt.filename)
+		}
+	}
+}
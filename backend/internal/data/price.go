@@ -0,0 +1,176 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PriceRange is a parsed price such as "$75,000-150,000/acre" or "$0.0972/kWh".
+// A single price has Min == Max. Unit is empty when the text names none.
+type PriceRange struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Unit     string  `json:"unit,omitempty"`
+	Currency string  `json:"currency"`
+}
+
+// Price units understood by PriceRange.Per.
+const (
+	UnitAcre    = "acre"
+	UnitHectare = "hectare"
+	UnitSqFt    = "sqft"
+	UnitKWh     = "kWh"
+	UnitMWh     = "MWh"
+)
+
+// unitAliases maps lower-cased unit spellings to the canonical unit.
+var unitAliases = map[string]string{
+	"acre": UnitAcre, "acres": UnitAcre, "ac": UnitAcre,
+	"hectare": UnitHectare, "hectares": UnitHectare, "ha": UnitHectare,
+	"sqft": UnitSqFt, "sq ft": UnitSqFt, "sq. ft.": UnitSqFt, "square foot": UnitSqFt, "ft2": UnitSqFt,
+	"kwh": UnitKWh, "mwh": UnitMWh,
+}
+
+// unitScale is how many of the base unit (acre, kWh) one unit holds.
+var unitScale = map[string]struct {
+	base  string
+	scale float64
+}{
+	UnitAcre:    {UnitAcre, 1},
+	UnitHectare: {UnitAcre, 2.471},
+	UnitSqFt:    {UnitAcre, 1.0 / 43560},
+	UnitKWh:     {UnitKWh, 1},
+	UnitMWh:     {UnitKWh, 1000},
+}
+
+var priceBound = regexp.MustCompile(`^([0-9][0-9,]*(?:\.[0-9]+)?|\.[0-9]+)\s*([kmb]?)$`)
+
+var suffixMultiplier = map[string]float64{"": 1, "k": 1e3, "m": 1e6, "b": 1e9}
+
+// ParsePriceRange reads prices like "$75,000-150,000/acre", "1.5-2.5M per acre",
+// "$0.06-0.08/kWh" and "$2,000,000". A K/M/B suffix on the upper bound alone applies to
+// both bounds. Prices without a currency symbol are taken to be US dollars.
+func ParsePriceRange(s string) (PriceRange, error) {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return PriceRange{}, fmt.Errorf("empty price")
+	}
+	r := PriceRange{Currency: "USD"}
+
+	amount, unit := text, ""
+	if i := strings.Index(amount, "/"); i >= 0 {
+		amount, unit = amount[:i], amount[i+1:]
+	} else if i := strings.Index(strings.ToLower(amount), " per "); i >= 0 {
+		amount, unit = amount[:i], amount[i+len(" per "):]
+	}
+	if unit = strings.ToLower(strings.TrimSpace(unit)); unit != "" {
+		canonical, ok := unitAliases[unit]
+		if !ok {
+			return PriceRange{}, fmt.Errorf("unknown price unit %q in %q", unit, s)
+		}
+		r.Unit = canonical
+	}
+
+	amount = strings.TrimSpace(amount)
+	if strings.HasPrefix(strings.ToUpper(amount), "USD") {
+		amount = amount[3:]
+	}
+	amount = strings.TrimSpace(strings.TrimPrefix(amount, "$"))
+
+	bounds := strings.Split(strings.ToLower(amount), "-")
+	if len(bounds) > 2 {
+		return PriceRange{}, fmt.Errorf("cannot parse price %q", s)
+	}
+	values := make([]float64, len(bounds))
+	suffixes := make([]string, len(bounds))
+	for i, b := range bounds {
+		m := priceBound.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(b), "$")))
+		if m == nil {
+			return PriceRange{}, fmt.Errorf("cannot parse price %q", s)
+		}
+		v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+		if err != nil {
+			return PriceRange{}, fmt.Errorf("cannot parse price %q: %w", s, err)
+		}
+		values[i], suffixes[i] = v, m[2]
+	}
+	if len(values) == 2 && suffixes[0] == "" {
+		suffixes[0] = suffixes[1]
+	}
+	for i := range values {
+		values[i] *= suffixMultiplier[suffixes[i]]
+	}
+
+	r.Min, r.Max = values[0], values[len(values)-1]
+	if r.Min > r.Max {
+		return PriceRange{}, fmt.Errorf("price range %q runs backwards", s)
+	}
+	return r, nil
+}
+
+// Mid is the midpoint of the range.
+func (r PriceRange) Mid() float64 {
+	return (r.Min + r.Max) / 2
+}
+
+// Per converts the range to the given unit, e.g. a price per hectare to one per acre.
+func (r PriceRange) Per(unit string) (PriceRange, error) {
+	from, ok := unitScale[r.Unit]
+	to, ok2 := unitScale[unit]
+	if !ok || !ok2 || from.base != to.base {
+		return PriceRange{}, fmt.Errorf("cannot convert a price per %q to one per %q", r.Unit, unit)
+	}
+	factor := to.scale / from.scale
+	r.Min *= factor
+	r.Max *= factor
+	r.Unit = unit
+	return r, nil
+}
+
+// LandPricePerAcre parses the location's land price as dollars per acre.
+func (loc *DatacenterLocation) LandPricePerAcre() (PriceRange, error) {
+	r, err := ParsePriceRange(loc.LandPrice)
+	if err != nil {
+		return r, err
+	}
+	return r.Per(UnitAcre)
+}
+
+// ElectricityPerKWh parses the location's electricity rate as dollars per kWh.
+func (loc *DatacenterLocation) ElectricityPerKWh() (PriceRange, error) {
+	r, err := ParsePriceRange(loc.Electricity)
+	if err != nil {
+		return r, err
+	}
+	return r.Per(UnitKWh)
+}
+
+// PriceParseError reports a location whose land price or electricity could not be parsed.
+type PriceParseError struct {
+	Row   int    `json:"row"` // 1-based position among the locations
+	Name  string `json:"name"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Err   string `json:"error"`
+}
+
+func (e PriceParseError) Error() string {
+	return fmt.Sprintf("row %d (%s): %s %q: %s", e.Row, e.Name, e.Field, e.Value, e.Err)
+}
+
+// CheckPrices parses every location's land price and electricity rate and reports the failures.
+func CheckPrices(locs []DatacenterLocation) []PriceParseError {
+	var report []PriceParseError
+	for i := range locs {
+		loc := &locs[i]
+		if _, err := loc.LandPricePerAcre(); err != nil {
+			report = append(report, PriceParseError{Row: i + 1, Name: loc.Name, Field: "land_price", Value: loc.LandPrice, Err: err.Error()})
+		}
+		if _, err := loc.ElectricityPerKWh(); err != nil {
+			report = append(report, PriceParseError{Row: i + 1, Name: loc.Name, Field: "electricity", Value: loc.Electricity, Err: err.Error()})
+		}
+	}
+	return report
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePriceRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PriceRange
+	}{
+		{"$75,000-150,000/acre", PriceRange{Min: 75000, Max: 150000, Unit: UnitAcre, Currency: "USD"}},
+		{"1.5-2.5M per acre", PriceRange{Min: 1.5e6, Max: 2.5e6, Unit: UnitAcre, Currency: "USD"}},
+		{"800K-1.2M per acre", PriceRange{Min: 8e5, Max: 1.2e6, Unit: UnitAcre, Currency: "USD"}},
+		{"$0.06-0.08/kWh", PriceRange{Min: 0.06, Max: 0.08, Unit: UnitKWh, Currency: "USD"}},
+		{"$0.0972/kWh", PriceRange{Min: 0.0972, Max: 0.0972, Unit: UnitKWh, Currency: "USD"}},
+		{"USD 40/MWh", PriceRange{Min: 40, Max: 40, Unit: UnitMWh, Currency: "USD"}},
+		{"$2,000,000", PriceRange{Min: 2e6, Max: 2e6, Currency: "USD"}},
+		{"$10 - $20 / Sq Ft", PriceRange{Min: 10, Max: 20, Unit: UnitSqFt, Currency: "USD"}},
+		{" .5k/ha ", PriceRange{Min: 500, Max: 500, Unit: UnitHectare, Currency: "USD"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriceRange(tt.in)
+		if err != nil {
+			t.Errorf("ParsePriceRange(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePriceRange(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePriceRangeErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"   ",
+		"call for pricing",
+		"$100/furlong",
+		"1-2-3/acre",
+		"$200-100/acre",
+		"$-5/acre",
+		"1.2.3M per acre",
+	} {
+		if got, err := ParsePriceRange(in); err == nil {
+			t.Errorf("ParsePriceRange(%q) = %+v, expected an error", in, got)
+		}
+	}
+}
+
+func TestPriceRangePer(t *testing.T) {
+	tests := []struct {
+		in       string
+		unit     string
+		min, max float64
+	}{
+		{"$100-200/acre", UnitAcre, 100, 200},
+		{"$247.1/hectare", UnitAcre, 100, 100},
+		{"$1/sqft", UnitAcre, 43560, 43560},
+		{"$40-60/MWh", UnitKWh, 0.04, 0.06},
+		{"$0.05/kWh", UnitMWh, 50, 50},
+	}
+	for _, tt := range tests {
+		r, err := ParsePriceRange(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.Per(tt.unit)
+		if err != nil {
+			t.Errorf("%q per %s: %v", tt.in, tt.unit, err)
+			continue
+		}
+		if math.Abs(got.Min-tt.min) > 1e-9*tt.min || math.Abs(got.Max-tt.max) > 1e-9*tt.max || got.Unit != tt.unit {
+			t.Errorf("%q per %s = %+v, want %g-%g", tt.in, tt.unit, got, tt.min, tt.max)
+		}
+	}
+
+	for _, tt := range []struct{ in, unit string }{
+		{"$100/acre", UnitKWh},
+		{"$0.05/kWh", UnitAcre},
+		{"$2,000,000", UnitAcre},
+	} {
+		r, _ := ParsePriceRange(tt.in)
+		if _, err := r.Per(tt.unit); err == nil {
+			t.Errorf("%q per %s: expected an error", tt.in, tt.unit)
+		}
+	}
+}
+
+func TestCheckPrices(t *testing.T) {
+	locs := []DatacenterLocation{
+		{Name: "Good", LandPrice: "$1-2/acre", Electricity: "$0.05/kWh"},
+		{Name: "Bad land", LandPrice: "negotiable", Electricity: "$0.05/kWh"},
+		{Name: "Swapped", LandPrice: "$0.05/kWh", Electricity: "$1-2/acre"},
+	}
+	report := CheckPrices(locs)
+	want := []struct {
+		row   int
+		field string
+	}{{2, "land_price"}, {3, "land_price"}, {3, "electricity"}}
+	if len(report) != len(want) {
+		t.Fatalf("got %d failures, want %d: %v", len(report), len(want), report)
+	}
+	for i, w := range want {
+		if report[i].Row != w.row || report[i].Field != w.field {
+			t.Errorf("failure %d = %+v, want row %d %s", i, report[i], w.row, w.field)
+		}
+	}
+}
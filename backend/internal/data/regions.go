@@ -0,0 +1,148 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RegionConfig describes the site datasets and environmental tables of one country, so
+// sites outside the US can be loaded side-by-side with the default US files.
+type RegionConfig struct {
+	Country             string `json:"country"` // ISO 3166-1 alpha-2
+	Name                string `json:"name"`
+	PossibleLocations   string `json:"possible_locations"`
+	ExistingDatacenters string `json:"existing_datacenters"`
+	// Tables is an optional directory with a manifest.json, laid out like internal/data/tables.
+	// Without it the country uses the national indicators.
+	Tables string `json:"tables,omitempty"`
+}
+
+// RegionInfo summarises a loaded region for listing.
+type RegionInfo struct {
+	Country             string `json:"country"`
+	Name                string `json:"name"`
+	PossibleLocations   int    `json:"possible_locations"`
+	ExistingDatacenters int    `json:"existing_datacenters"`
+	Tables              string `json:"tables"`
+}
+
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// ErrUnknownRegion is returned for a country without loaded datasets.
+var ErrUnknownRegion = errors.New("unknown region")
+
+var (
+	regions   = make(map[string]*regionEntry)
+	regionsMu sync.RWMutex
+)
+
+type regionEntry struct {
+	config   RegionConfig
+	registry *Registry
+}
+
+// LoadRegions reads a JSON array of RegionConfig. Relative paths are taken from the
+// directory of the file.
+func LoadRegions(filename string) ([]RegionConfig, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var configs []RegionConfig
+	if err := json.Unmarshal(content, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	dir := filepath.Dir(filename)
+	for i := range configs {
+		c := &configs[i]
+		for _, p := range []*string{&c.PossibleLocations, &c.ExistingDatacenters, &c.Tables} {
+			if *p != "" && !filepath.IsAbs(*p) {
+				*p = filepath.Join(dir, *p)
+			}
+		}
+	}
+	return configs, nil
+}
+
+// AddRegion loads a region's datasets and tables and makes them available through
+// RegionRegistry and EnvironmentalProviderFor. Adding a country again replaces it.
+func AddRegion(c RegionConfig) (*Registry, error) {
+	c.Country = strings.ToUpper(c.Country)
+	if !countryCodePattern.MatchString(c.Country) {
+		return nil, fmt.Errorf("invalid country code %q", c.Country)
+	}
+	if c.Country == usCountryCode {
+		return nil, fmt.Errorf("the US region uses the default datasets")
+	}
+	if c.PossibleLocations == "" || c.ExistingDatacenters == "" {
+		return nil, fmt.Errorf("region %s: possible_locations and existing_datacenters are required", c.Country)
+	}
+
+	registry := NewRegistry(c.PossibleLocations, c.ExistingDatacenters)
+	registry.country = c.Country
+	registry.namedDir = filepath.Join(DefaultNamedDatasetDir, strings.ToLower(c.Country))
+	if err := registry.Reload(); err != nil {
+		return nil, fmt.Errorf("region %s: %w", c.Country, err)
+	}
+	if c.Tables != "" {
+		p, err := LoadTableProviderDir(c.Tables)
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", c.Country, err)
+		}
+		SetRegionProvider(c.Country, p)
+	}
+
+	regionsMu.Lock()
+	defer regionsMu.Unlock()
+	regions[c.Country] = &regionEntry{config: c, registry: registry}
+	return registry, nil
+}
+
+// RegionRegistry returns the registry of a country's datasets. The empty code and "US"
+// return DefaultRegistry.
+func RegionRegistry(country string) (*Registry, error) {
+	country = strings.ToUpper(country)
+	if country == "" || country == usCountryCode {
+		return DefaultRegistry(), nil
+	}
+	regionsMu.RLock()
+	defer regionsMu.RUnlock()
+	entry, ok := regions[country]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRegion, country)
+	}
+	return entry.registry, nil
+}
+
+// Regions lists the US and every added region, sorted by country code.
+func Regions() []RegionInfo {
+	infos := []RegionInfo{regionInfo(RegionConfig{Country: usCountryCode, Name: "United States"}, DefaultRegistry())}
+
+	regionsMu.RLock()
+	for _, entry := range regions {
+		infos = append(infos, regionInfo(entry.config, entry.registry))
+	}
+	regionsMu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Country < infos[j].Country })
+	return infos
+}
+
+func regionInfo(c RegionConfig, r *Registry) RegionInfo {
+	info := RegionInfo{Country: c.Country, Name: c.Name, Tables: "national"}
+	if c.Tables != "" || c.Country == usCountryCode {
+		info.Tables = "full"
+	}
+	if s := r.current.Load(); s != nil {
+		info.PossibleLocations = len(s.PossibleLocations.Rows)
+		info.ExistingDatacenters = len(s.ExistingDatacenters.Rows)
+	}
+	return info
+}
@@ -0,0 +1,290 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default dataset paths, relative to the server's working directory.
+const (
+	DefaultPossibleLocationsFile   = "us_possible_locations.csv"
+	DefaultExistingDatacentersFile = "us_datacenters.csv"
+	DefaultNamedDatasetDir         = "./datasets"
+)
+
+// Snapshot is one loaded version of the site datasets. It is shared between requests and
+// must not be modified; copy the rows before changing them.
+type Snapshot struct {
+	PossibleLocations   Dataset
+	ExistingDatacenters Dataset
+	LoadedAt            time.Time
+	// Digest is a SHA-256 of both files' contents, for keying caches of derived data.
+	Digest string
+}
+
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Registry holds the current Snapshot and replaces it atomically when the files change.
+type Registry struct {
+	possiblePath string
+	existingPath string
+	country      string // ISO code of the region's sites; empty for the US
+
+	current atomic.Pointer[Snapshot]
+
+	mu     sync.Mutex // serialises reloads
+	stamps [2]fileStamp
+
+	// named holds uploaded candidate-site datasets, persisted as <namedDir>/<name>.json.
+	named    map[string]*Dataset
+	namedMu  sync.RWMutex
+	namedDir string
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// DefaultRegistry returns the shared registry of the default dataset files. It is empty
+// until Reload succeeds.
+func DefaultRegistry() *Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewRegistry(DefaultPossibleLocationsFile, DefaultExistingDatacentersFile)
+		defaultRegistry.namedDir = DefaultNamedDatasetDir
+	})
+	return defaultRegistry
+}
+
+// NewRegistry creates a registry for the two dataset files. Call Reload to load them.
+// Named datasets are kept in memory only unless the registry is the default one or a region's.
+func NewRegistry(possiblePath, existingPath string) *Registry {
+	return &Registry{possiblePath: possiblePath, existingPath: existingPath, named: make(map[string]*Dataset)}
+}
+
+// Snapshot returns the current datasets, loading them first if nothing has been loaded yet.
+func (r *Registry) Snapshot() (*Snapshot, error) {
+	if s := r.current.Load(); s != nil {
+		return s, nil
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r.current.Load(), nil
+}
+
+// Reload reads both files and swaps in the new snapshot. On error the previous snapshot
+// stays in place.
+func (r *Registry) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reloadLocked()
+}
+
+func (r *Registry) reloadLocked() error {
+	stamps, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+	possible, err := LoadDataset(r.possiblePath, PossibleLocationsSchema.ForCountry(r.country))
+	if err != nil {
+		return err
+	}
+	existing, err := LoadDataset(r.existingPath, ExistingDatacentersSchema.ForCountry(r.country))
+	if err != nil {
+		return err
+	}
+	digest, err := digestFiles(r.possiblePath, r.existingPath)
+	if err != nil {
+		return err
+	}
+	r.current.Store(&Snapshot{
+		PossibleLocations:   possible,
+		ExistingDatacenters: existing,
+		LoadedAt:            time.Now(),
+		Digest:              digest,
+	})
+	r.stamps = stamps
+	return nil
+}
+
+// digestFiles hashes the contents of the files in order.
+func digestFiles(paths ...string) (string, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (r *Registry) statFiles() ([2]fileStamp, error) {
+	var stamps [2]fileStamp
+	for i, path := range []string{r.possiblePath, r.existingPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return stamps, fmt.Errorf("stat %s: %w", path, err)
+		}
+		stamps[i] = fileStamp{info.ModTime(), info.Size()}
+	}
+	return stamps, nil
+}
+
+// reloadIfChanged reloads when either file's modification time or size has changed.
+func (r *Registry) reloadIfChanged() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stamps, err := r.statFiles()
+	if err != nil {
+		return false, err
+	}
+	if stamps == r.stamps && r.current.Load() != nil {
+		return false, nil
+	}
+	return true, r.reloadLocked()
+}
+
+// Watch polls the files every interval and reloads them when they change, until stop is
+// closed. Failed reloads are logged and keep the previous snapshot.
+func (r *Registry) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := r.reloadIfChanged()
+			if err != nil {
+				log.Printf("Warning: could not reload datasets: %v", err)
+			} else if reloaded {
+				log.Printf("Reloaded datasets from %s and %s", r.possiblePath, r.existingPath)
+			}
+		}
+	}
+}
+
+var datasetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// ErrUnknownDataset is returned for a dataset name that has not been uploaded.
+var ErrUnknownDataset = errors.New("unknown dataset")
+
+// LoadNamed reads every saved named dataset when the app starts.
+func (r *Registry) LoadNamed() error {
+	if r.namedDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(r.namedDir, 0755); err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(r.namedDir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	r.namedMu.Lock()
+	defer r.namedMu.Unlock()
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		var ds Dataset
+		if err := json.Unmarshal(content, &ds); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+		r.named[strings.TrimSuffix(filepath.Base(f), ".json")] = &ds
+	}
+	return nil
+}
+
+// SaveNamed stores ds as the candidate sites of a named dataset, replacing any dataset of
+// the same name. Names are letters, digits, '-' and '_'.
+func (r *Registry) SaveNamed(name string, ds Dataset) error {
+	if !datasetNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid dataset name %q", name)
+	}
+	r.namedMu.Lock()
+	defer r.namedMu.Unlock()
+	if r.namedDir != "" {
+		if err := os.MkdirAll(r.namedDir, 0755); err != nil {
+			return err
+		}
+		content, err := json.MarshalIndent(ds, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(r.namedDir, name+".json"), content, 0644); err != nil {
+			return err
+		}
+	}
+	r.named[name] = &ds
+	return nil
+}
+
+// Named returns a named dataset. Like a Snapshot it is shared and must not be modified.
+func (r *Registry) Named(name string) (*Dataset, error) {
+	r.namedMu.RLock()
+	defer r.namedMu.RUnlock()
+	ds, ok := r.named[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownDataset, name)
+	}
+	return ds, nil
+}
+
+// NamedDatasets lists the named datasets and their site counts.
+func (r *Registry) NamedDatasets() map[string]int {
+	r.namedMu.RLock()
+	defer r.namedMu.RUnlock()
+	counts := make(map[string]int, len(r.named))
+	for name, ds := range r.named {
+		counts[name] = len(ds.Rows)
+	}
+	return counts
+}
+
+// Country returns the ISO code of the registry's region, or "" for the US.
+func (r *Registry) Country() string {
+	return r.country
+}
+
+// CandidateSites returns the candidate locations of a named dataset, or of the possible
+// locations CSV when name is empty.
+func (r *Registry) CandidateSites(name string) ([]DatacenterLocation, error) {
+	if name != "" {
+		ds, err := r.Named(name)
+		if err != nil {
+			return nil, err
+		}
+		return ds.Rows, nil
+	}
+	s, err := r.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.PossibleLocations.Rows, nil
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testPossible = "latitude,longitude,location name,land price,electricity,notes\n" +
+		`34.7304,-86.5861,"Huntsville, AL","$75,000-150,000/acre","$0.0972/kWh","{notes:["Growing tech hub"]}"` + "\n"
+	testExisting = "latitude,longitude,location_name,land_price,electricity,notes,source\n" +
+		"39.0438,-77.4874,AWS US East Region (Ashburn VA),1.5-2.5M per acre,$0.06-0.08/kWh,Largest region,AWS\n"
+)
+
+// testRegistry writes the two datasets to a temporary directory and returns a registry over them.
+func testRegistry(t *testing.T) (*Registry, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	possible, existing := filepath.Join(dir, "possible.csv"), filepath.Join(dir, "existing.csv")
+	writeFile(t, possible, testPossible)
+	writeFile(t, existing, testExisting)
+	return NewRegistry(possible, existing), possible, existing
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Move the modification time on, as a file rewritten within the clock's resolution
+	// would otherwise look unchanged.
+	later := time.Now().Add(time.Duration(len(content)) * time.Second)
+	os.Chtimes(path, later, later)
+}
+
+func TestRegistrySnapshot(t *testing.T) {
+	r, _, _ := testRegistry(t)
+	first, err := r.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.PossibleLocations.Rows) != 1 || len(first.ExistingDatacenters.Rows) != 1 || first.Digest == "" {
+		t.Fatalf("loaded %d possible and %d existing rows, digest %q",
+			len(first.PossibleLocations.Rows), len(first.ExistingDatacenters.Rows), first.Digest)
+	}
+	second, err := r.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("Snapshot reloaded unchanged files")
+	}
+	if reloaded, err := r.reloadIfChanged(); err != nil || reloaded {
+		t.Errorf("reloadIfChanged on unchanged files: %v, %v", reloaded, err)
+	}
+}
+
+func TestRegistryReloadsChangedFiles(t *testing.T) {
+	r, possible, _ := testRegistry(t)
+	first, err := r.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, possible, testPossible+`33.5186,-86.8104,"Birmingham, AL","$50,000-100,000/acre","$0.0995/kWh","{notes:["Major business center"]}"`+"\n")
+	reloaded, err := r.reloadIfChanged()
+	if err != nil || !reloaded {
+		t.Fatalf("reloadIfChanged after a change: %v, %v", reloaded, err)
+	}
+	second, _ := r.Snapshot()
+	if second == first || len(second.PossibleLocations.Rows) != 2 || second.Digest == first.Digest {
+		t.Errorf("new snapshot has %d rows, digest changed %v", len(second.PossibleLocations.Rows), second.Digest != first.Digest)
+	}
+	if len(first.PossibleLocations.Rows) != 1 {
+		t.Error("the previous snapshot was modified")
+	}
+}
+
+func TestRegistryKeepsSnapshotOnFailedReload(t *testing.T) {
+	r, _, existing := testRegistry(t)
+	first, err := r.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, existing, "lat,lng\n1,2\n")
+	if err := r.Reload(); err == nil {
+		t.Fatal("reload of a file without the schema's columns succeeded")
+	}
+	if current, _ := r.Snapshot(); current != first {
+		t.Error("failed reload replaced the snapshot")
+	}
+	os.Remove(existing)
+	if _, err := r.reloadIfChanged(); err == nil {
+		t.Error("reload of a missing file succeeded")
+	}
+	if current, _ := r.Snapshot(); current != first {
+		t.Error("failed reload replaced the snapshot")
+	}
+}
+
+func TestRegistryNamedDatasets(t *testing.T) {
+	r, _, _ := testRegistry(t)
+	ds := Dataset{Rows: []DatacenterLocation{{Latitude: 45.5, Longitude: -122.6, Name: "Portland"}}}
+	if err := r.SaveNamed("../escape", ds); err == nil {
+		t.Error("invalid name accepted")
+	}
+	if err := r.SaveNamed("oregon", ds); err != nil {
+		t.Fatal(err)
+	}
+	sites, err := r.CandidateSites("oregon")
+	if err != nil || len(sites) != 1 || sites[0].Name != "Portland" {
+		t.Errorf("CandidateSites(oregon) = %v, %v", sites, err)
+	}
+	if _, err := r.CandidateSites("missing"); !errors.Is(err, ErrUnknownDataset) {
+		t.Errorf("unknown dataset: %v", err)
+	}
+	if sites, err := r.CandidateSites(""); err != nil || len(sites) != 1 || sites[0].Name != "Huntsville, AL" {
+		t.Errorf("CandidateSites(\"\") = %v, %v", sites, err)
+	}
+}
@@ -0,0 +1,146 @@
+package data
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// scenarioFiles holds the climate baseline scenarios shipped with the binary.
+//
+//go:embed scenarios/*.json
+var scenarioFiles embed.FS
+
+// DefaultScenarioName is the scenario used when none is selected.
+const DefaultScenarioName = "linear"
+
+// ScenarioValue is a scenario's baseline for one year.
+type ScenarioValue struct {
+	Year               int     `json:"year"`
+	Temperature        float64 `json:"temperature"`          // °C above pre-industrial
+	FossilFuelReserves float64 `json:"fossil_fuel_reserves"` // 1.0 -> 0
+}
+
+// ClimateScenario is a named baseline pathway, such as an SSP, with values by year.
+type ClimateScenario struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Source      string          `json:"source,omitempty"`
+	Values      []ScenarioValue `json:"values"`
+}
+
+// At returns the baseline for year, interpolating linearly between listed years and
+// holding the first and last values outside them.
+func (s *ClimateScenario) At(year int) ScenarioValue {
+	v := s.Values
+	i := sort.Search(len(v), func(i int) bool { return v[i].Year >= year })
+	switch {
+	case i == len(v):
+		return ScenarioValue{year, v[i-1].Temperature, v[i-1].FossilFuelReserves}
+	case v[i].Year == year || i == 0:
+		return ScenarioValue{year, v[i].Temperature, v[i].FossilFuelReserves}
+	}
+	a, b := v[i-1], v[i]
+	f := float64(year-a.Year) / float64(b.Year-a.Year)
+	return ScenarioValue{
+		Year:               year,
+		Temperature:        a.Temperature + f*(b.Temperature-a.Temperature),
+		FossilFuelReserves: a.FossilFuelReserves + f*(b.FossilFuelReserves-a.FossilFuelReserves),
+	}
+}
+
+func (s *ClimateScenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario has no name")
+	}
+	if len(s.Values) == 0 {
+		return fmt.Errorf("scenario %s has no values", s.Name)
+	}
+	sort.Slice(s.Values, func(i, j int) bool { return s.Values[i].Year < s.Values[j].Year })
+	for i := 1; i < len(s.Values); i++ {
+		if s.Values[i].Year == s.Values[i-1].Year {
+			return fmt.Errorf("scenario %s lists %d twice", s.Name, s.Values[i].Year)
+		}
+	}
+	return nil
+}
+
+var (
+	scenarios   = mustLoadScenarios(scenarioFiles, "scenarios")
+	scenariosMu sync.RWMutex
+)
+
+func mustLoadScenarios(fsys fs.FS, dir string) map[string]*ClimateScenario {
+	loaded, err := loadScenarios(fsys, dir)
+	if err != nil {
+		panic(fmt.Sprintf("loading embedded climate scenarios: %v", err))
+	}
+	m := make(map[string]*ClimateScenario, len(loaded))
+	for _, s := range loaded {
+		m[strings.ToLower(s.Name)] = s
+	}
+	return m
+}
+
+func loadScenarios(fsys fs.FS, dir string) ([]*ClimateScenario, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var loaded []*ClimateScenario
+	for _, f := range files {
+		s := &ClimateScenario{}
+		if err := readJSON(fsys, f, s); err != nil {
+			return nil, err
+		}
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		loaded = append(loaded, s)
+	}
+	return loaded, nil
+}
+
+// LoadScenarioDir adds every *.json scenario in dir, replacing built-in scenarios of the
+// same name.
+func LoadScenarioDir(dir string) error {
+	loaded, err := loadScenarios(os.DirFS(dir), ".")
+	if err != nil {
+		return err
+	}
+	scenariosMu.Lock()
+	defer scenariosMu.Unlock()
+	for _, s := range loaded {
+		scenarios[strings.ToLower(s.Name)] = s
+	}
+	return nil
+}
+
+// Scenario returns the named scenario, ignoring case, or the default one for "".
+func Scenario(name string) (*ClimateScenario, bool) {
+	if name == "" {
+		name = DefaultScenarioName
+	}
+	scenariosMu.RLock()
+	defer scenariosMu.RUnlock()
+	s, ok := scenarios[strings.ToLower(name)]
+	return s, ok
+}
+
+// Scenarios lists every scenario by name.
+func Scenarios() []*ClimateScenario {
+	scenariosMu.RLock()
+	defer scenariosMu.RUnlock()
+	list := make([]*ClimateScenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
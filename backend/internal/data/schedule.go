@@ -0,0 +1,42 @@
+package data
+
+import "fmt"
+
+// OperatingSchedule is when a facility runs. Zero years are open: an unset commission year
+// means it is already running, an unset decommission year that it never closes.
+type OperatingSchedule struct {
+	CommissionYear   int `json:"commission_year,omitempty"`
+	DecommissionYear int `json:"decommission_year,omitempty"` // first year it no longer runs
+	// RampUpYears spreads the build-out from commissioning to full load evenly over this
+	// many years; 0 runs at full load from the commission year.
+	RampUpYears int `json:"ramp_up_years,omitempty"`
+}
+
+// Validate checks that the years are in order.
+func (s OperatingSchedule) Validate() error {
+	switch {
+	case s.RampUpYears < 0:
+		return fmt.Errorf("ramp_up_years must not be negative")
+	case s.CommissionYear != 0 && s.DecommissionYear != 0 && s.DecommissionYear <= s.CommissionYear:
+		return fmt.Errorf("decommission_year %d must be after commission_year %d", s.DecommissionYear, s.CommissionYear)
+	}
+	return nil
+}
+
+// LoadFactor returns the share of full load the facility runs at in year, from 0 before
+// commissioning and after decommissioning to 1 once ramped up.
+func (s OperatingSchedule) LoadFactor(year int) float64 {
+	if s.DecommissionYear != 0 && year >= s.DecommissionYear {
+		return 0
+	}
+	if s.CommissionYear == 0 {
+		return 1
+	}
+	if year < s.CommissionYear {
+		return 0
+	}
+	if elapsed := year - s.CommissionYear + 1; elapsed < s.RampUpYears {
+		return float64(elapsed) / float64(s.RampUpYears)
+	}
+	return 1
+}
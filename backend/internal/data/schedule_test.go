@@ -0,0 +1,52 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOperatingScheduleLoadFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule OperatingSchedule
+		year     int
+		want     float64
+	}{
+		{"always running", OperatingSchedule{}, 2030, 1},
+		{"already running, ramp ignored", OperatingSchedule{RampUpYears: 4}, 2030, 1},
+		{"before commissioning", OperatingSchedule{CommissionYear: 2028}, 2027, 0},
+		{"commission year", OperatingSchedule{CommissionYear: 2028}, 2028, 1},
+		{"first ramp year", OperatingSchedule{CommissionYear: 2028, RampUpYears: 4}, 2028, 0.25},
+		{"mid ramp", OperatingSchedule{CommissionYear: 2028, RampUpYears: 4}, 2030, 0.75},
+		{"ramped up", OperatingSchedule{CommissionYear: 2028, RampUpYears: 4}, 2031, 1},
+		{"one-year ramp", OperatingSchedule{CommissionYear: 2028, RampUpYears: 1}, 2028, 1},
+		{"last running year", OperatingSchedule{CommissionYear: 2028, DecommissionYear: 2040}, 2039, 1},
+		{"decommission year", OperatingSchedule{CommissionYear: 2028, DecommissionYear: 2040}, 2040, 0},
+		{"closes without a commission year", OperatingSchedule{DecommissionYear: 2035}, 2036, 0},
+		{"closes during ramp", OperatingSchedule{CommissionYear: 2028, DecommissionYear: 2030, RampUpYears: 5}, 2030, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.schedule.LoadFactor(tt.year); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: LoadFactor(%d) = %g, want %g", tt.name, tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestOperatingScheduleValidate(t *testing.T) {
+	tests := []struct {
+		schedule OperatingSchedule
+		ok       bool
+	}{
+		{OperatingSchedule{}, true},
+		{OperatingSchedule{CommissionYear: 2028, DecommissionYear: 2029, RampUpYears: 3}, true},
+		{OperatingSchedule{DecommissionYear: 2020}, true},
+		{OperatingSchedule{RampUpYears: -1}, false},
+		{OperatingSchedule{CommissionYear: 2030, DecommissionYear: 2030}, false},
+		{OperatingSchedule{CommissionYear: 2030, DecommissionYear: 2025}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.schedule.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok %v", tt.schedule, err, tt.ok)
+		}
+	}
+}
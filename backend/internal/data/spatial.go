@@ -0,0 +1,178 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DensityRadiusKm is the radius used to count neighbouring datacenters.
+	DensityRadiusKm = 50.0
+
+	// indexCellDeg is the edge length of an index bucket in degrees (~55 km of latitude).
+	indexCellDeg = 0.5
+
+	kmPerDegree = 111.195
+)
+
+// Neighbour is a site returned from a SpatialIndex query.
+type Neighbour struct {
+	Location   DatacenterLocation `json:"location"`
+	DistanceKm float64            `json:"distance_km"`
+}
+
+type cellKey struct {
+	lat, lng int
+}
+
+// SpatialIndex buckets sites into a fixed lat/lng grid so radius and k-nearest
+// queries only visit the cells around the query point.
+type SpatialIndex struct {
+	cells map[cellKey][]DatacenterLocation
+	size  int
+
+	minCell, maxCell cellKey
+}
+
+// NewSpatialIndex builds an index over the given sites. Further sites can be added with Insert.
+func NewSpatialIndex(sites ...[]DatacenterLocation) *SpatialIndex {
+	idx := &SpatialIndex{cells: make(map[cellKey][]DatacenterLocation)}
+	for _, list := range sites {
+		for _, s := range list {
+			idx.Insert(s)
+		}
+	}
+	return idx
+}
+
+// Insert adds a site to the index.
+func (idx *SpatialIndex) Insert(loc DatacenterLocation) {
+	key := cellFor(loc.Latitude, loc.Longitude)
+	if idx.size == 0 {
+		idx.minCell, idx.maxCell = key, key
+	} else {
+		idx.minCell.lat = min(idx.minCell.lat, key.lat)
+		idx.minCell.lng = min(idx.minCell.lng, key.lng)
+		idx.maxCell.lat = max(idx.maxCell.lat, key.lat)
+		idx.maxCell.lng = max(idx.maxCell.lng, key.lng)
+	}
+	idx.cells[key] = append(idx.cells[key], loc)
+	idx.size++
+}
+
+// Len returns the number of indexed sites.
+func (idx *SpatialIndex) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return idx.size
+}
+
+// ValidCoordinate reports whether lat and lng are finite and within the globe's range.
+func ValidCoordinate(lat, lng float64) bool {
+	// Written as positive comparisons so NaN, which compares false with everything, fails.
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// Within returns every site within radiusKm of the point, nearest first. It returns nil
+// for coordinates that are not valid.
+func (idx *SpatialIndex) Within(lat, lng, radiusKm float64) []Neighbour {
+	if idx.Len() == 0 || !ValidCoordinate(lat, lng) || !(radiusKm >= 0) || math.IsInf(radiusKm, 0) {
+		return nil
+	}
+	dLat := radiusKm / kmPerDegree
+	dLng := radiusKm / (kmPerDegree * math.Max(math.Cos((math.Abs(lat)+dLat)*math.Pi/180.0), 0.01))
+	lo := cellFor(lat-dLat, lng-dLng)
+	hi := cellFor(lat+dLat, lng+dLng)
+
+	var result []Neighbour
+	for cy := lo.lat; cy <= hi.lat; cy++ {
+		for cx := lo.lng; cx <= hi.lng; cx++ {
+			for _, s := range idx.cells[cellKey{cy, cx}] {
+				if d := distance(lat, lng, s.Latitude, s.Longitude); d <= radiusKm {
+					result = append(result, Neighbour{Location: s, DistanceKm: d})
+				}
+			}
+		}
+	}
+	sortNeighbours(result)
+	return result
+}
+
+// Nearest returns the k sites closest to the point, nearest first. It returns nil for
+// coordinates that are not valid.
+func (idx *SpatialIndex) Nearest(lat, lng float64, k int) []Neighbour {
+	if idx.Len() == 0 || k <= 0 || !ValidCoordinate(lat, lng) {
+		return nil
+	}
+	center := cellFor(lat, lng)
+	maxRing := max(
+		abs(center.lat-idx.minCell.lat), abs(idx.maxCell.lat-center.lat),
+		abs(center.lng-idx.minCell.lng), abs(idx.maxCell.lng-center.lng),
+	)
+
+	var candidates []Neighbour
+	for ring := 0; ring <= maxRing; ring++ {
+		idx.visitRing(center, ring, func(s DatacenterLocation) {
+			candidates = append(candidates, Neighbour{Location: s, DistanceKm: distance(lat, lng, s.Latitude, s.Longitude)})
+		})
+		if len(candidates) < k {
+			continue
+		}
+		// Anything in a further ring is at least ring cells away in latitude or longitude.
+		sortNeighbours(candidates)
+		farLat := math.Min(math.Abs(lat)+float64(ring+1)*indexCellDeg, 89.9)
+		bound := float64(ring) * indexCellDeg * kmPerDegree * math.Cos(farLat*math.Pi/180.0)
+		if candidates[k-1].DistanceKm <= bound {
+			break
+		}
+	}
+	sortNeighbours(candidates)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	return candidates
+}
+
+// CountWithin counts the sites within radiusKm of loc, not counting loc itself.
+func (idx *SpatialIndex) CountWithin(loc *DatacenterLocation, radiusKm float64) int {
+	count := 0
+	for _, n := range idx.Within(loc.Latitude, loc.Longitude, radiusKm) {
+		if n.DistanceKm < 0.001 && n.Location.Name == loc.Name {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func (idx *SpatialIndex) visitRing(center cellKey, ring int, fn func(DatacenterLocation)) {
+	for cy := center.lat - ring; cy <= center.lat+ring; cy++ {
+		for cx := center.lng - ring; cx <= center.lng+ring; cx++ {
+			if abs(cy-center.lat) != ring && abs(cx-center.lng) != ring {
+				continue
+			}
+			for _, s := range idx.cells[cellKey{cy, cx}] {
+				fn(s)
+			}
+		}
+	}
+}
+
+func cellFor(lat, lng float64) cellKey {
+	return cellKey{
+		lat: int(math.Floor(lat / indexCellDeg)),
+		lng: int(math.Floor(lng / indexCellDeg)),
+	}
+}
+
+func sortNeighbours(n []Neighbour) {
+	sort.Slice(n, func(i, j int) bool { return n[i].DistanceKm < n[j].DistanceKm })
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
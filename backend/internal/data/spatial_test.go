@@ -0,0 +1,128 @@
+package data
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// testSites scatters sites over the contiguous US and Alaska, with a dense cluster in
+// northern Virginia.
+func testSites() []DatacenterLocation {
+	rng := rand.New(rand.NewSource(1))
+	var sites []DatacenterLocation
+	for i := 0; i < 300; i++ {
+		sites = append(sites, DatacenterLocation{Latitude: 25 + rng.Float64()*24, Longitude: -124 + rng.Float64()*57})
+	}
+	for i := 0; i < 50; i++ {
+		sites = append(sites, DatacenterLocation{Latitude: 38.9 + rng.Float64()*0.2, Longitude: -77.5 + rng.Float64()*0.2})
+	}
+	for i := 0; i < 10; i++ {
+		sites = append(sites, DatacenterLocation{Latitude: 60 + rng.Float64()*10, Longitude: -160 + rng.Float64()*20})
+	}
+	return sites
+}
+
+func bruteForceNearest(sites []DatacenterLocation, lat, lng float64, k int) []float64 {
+	var d []float64
+	for _, s := range sites {
+		d = append(d, distance(lat, lng, s.Latitude, s.Longitude))
+	}
+	sort.Float64s(d)
+	return d[:min(k, len(d))]
+}
+
+func TestSpatialIndexNearest(t *testing.T) {
+	sites := testSites()
+	idx := NewSpatialIndex(sites)
+	tests := []struct {
+		name     string
+		lat, lng float64
+		k        int
+	}{
+		{"inside the cluster", 39.0, -77.4, 5},
+		{"next to the cluster", 38.0, -78.0, 20},
+		{"open country", 40.0, -100.0, 3},
+		{"offshore", 30.0, -60.0, 4},
+		{"high latitude", 64.8, -147.7, 3},
+		{"far from every site", -40.0, 100.0, 2},
+		{"more than indexed", 35.0, -90.0, len(sites) + 10},
+		{"single", 45.0, -120.0, 1},
+	}
+	for _, tt := range tests {
+		got := idx.Nearest(tt.lat, tt.lng, tt.k)
+		want := bruteForceNearest(sites, tt.lat, tt.lng, tt.k)
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d neighbours, want %d", tt.name, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i].DistanceKm-want[i]) > 1e-9 {
+				t.Errorf("%s: neighbour %d is %.3f km away, want %.3f", tt.name, i, got[i].DistanceKm, want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestSpatialIndexNearestEmpty(t *testing.T) {
+	if got := NewSpatialIndex().Nearest(39, -77, 3); got != nil {
+		t.Errorf("empty index returned %v", got)
+	}
+	var idx *SpatialIndex
+	if got := idx.Nearest(39, -77, 3); got != nil {
+		t.Errorf("nil index returned %v", got)
+	}
+	if got := NewSpatialIndex(testSites()).Nearest(39, -77, 0); got != nil {
+		t.Errorf("k = 0 returned %v", got)
+	}
+}
+
+func TestSpatialIndexWithin(t *testing.T) {
+	sites := testSites()
+	idx := NewSpatialIndex(sites)
+	for _, radius := range []float64{1, 10, DensityRadiusKm, 500} {
+		for _, p := range [][2]float64{{39.0, -77.4}, {40.0, -100.0}, {64.8, -147.7}} {
+			want := 0
+			for _, s := range sites {
+				if distance(p[0], p[1], s.Latitude, s.Longitude) <= radius {
+					want++
+				}
+			}
+			got := idx.Within(p[0], p[1], radius)
+			if len(got) != want {
+				t.Errorf("Within(%v, %g) found %d sites, want %d", p, radius, len(got), want)
+			}
+			if !sort.SliceIsSorted(got, func(i, j int) bool { return got[i].DistanceKm < got[j].DistanceKm }) {
+				t.Errorf("Within(%v, %g) is not nearest first", p, radius)
+			}
+		}
+	}
+}
+
+func TestSpatialIndexInvalidCoordinates(t *testing.T) {
+	idx := NewSpatialIndex(testSites())
+	nan, inf := math.NaN(), math.Inf(1)
+	tests := []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"NaN", nan, nan},
+		{"NaN latitude", nan, -77},
+		{"infinite longitude", 39, -inf},
+		{"latitude past the pole", 91, -77},
+		{"longitude past the antimeridian", 39, 181},
+	}
+	for _, tt := range tests {
+		if got := idx.Nearest(tt.lat, tt.lng, 3); got != nil {
+			t.Errorf("%s: Nearest returned %v", tt.name, got)
+		}
+		if got := idx.Within(tt.lat, tt.lng, DensityRadiusKm); got != nil {
+			t.Errorf("%s: Within returned %v", tt.name, got)
+		}
+	}
+	if got := idx.Within(39, -77, inf); got != nil {
+		t.Errorf("infinite radius returned %d sites", len(got))
+	}
+}
@@ -0,0 +1,74 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FeatureCollection is a GeoJSON FeatureCollection (RFC 7946).
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Coordinates stay raw until read with one of the accessors.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// NewFeatureCollection returns an empty FeatureCollection ready for appending.
+func NewFeatureCollection() FeatureCollection {
+	return FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+// PointFeature builds a Point feature at lat/lng with the given properties.
+func PointFeature(lat, lng float64, props map[string]interface{}) Feature {
+	coords, _ := json.Marshal([2]float64{lng, lat})
+	return Feature{
+		Type:       "Feature",
+		Geometry:   Geometry{Type: "Point", Coordinates: coords},
+		Properties: props,
+	}
+}
+
+// Point returns the coordinates of a Point geometry.
+func (g Geometry) Point() (lat, lng float64, err error) {
+	if g.Type != "Point" {
+		return 0, 0, fmt.Errorf("geometry is %s, not Point", g.Type)
+	}
+	var c []float64
+	if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+		return 0, 0, fmt.Errorf("invalid Point coordinates: %w", err)
+	}
+	if len(c) < 2 {
+		return 0, 0, fmt.Errorf("Point needs two coordinates, got %d", len(c))
+	}
+	return c[1], c[0], nil
+}
+
+// MultiPolygon returns the shape of a Polygon or MultiPolygon geometry.
+func (g Geometry) MultiPolygon() (MultiPolygon, error) {
+	switch g.Type {
+	case "Polygon":
+		var p Polygon
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		return MultiPolygon{p}, nil
+	case "MultiPolygon":
+		var mp MultiPolygon
+		if err := json.Unmarshal(g.Coordinates, &mp); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+		return mp, nil
+	}
+	return nil, fmt.Errorf("geometry is %s, not Polygon or MultiPolygon", g.Type)
+}
@@ -0,0 +1,99 @@
+package geo
+
+import "math"
+
+// Ring is a closed sequence of [lng, lat] positions.
+type Ring [][2]float64
+
+// Polygon is an outer ring followed by optional holes.
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons making up one shape.
+type MultiPolygon []Polygon
+
+// BBox is a lat/lng bounding box.
+type BBox struct {
+	MinLat, MinLng, MaxLat, MaxLng float64
+}
+
+// Contains reports whether the point lies inside the box.
+func (b BBox) Contains(lat, lng float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lng >= b.MinLng && lng <= b.MaxLng
+}
+
+// Bounds returns the bounding box of every outer ring.
+func (mp MultiPolygon) Bounds() BBox {
+	b := BBox{MinLat: math.Inf(1), MinLng: math.Inf(1), MaxLat: math.Inf(-1), MaxLng: math.Inf(-1)}
+	for _, p := range mp {
+		if len(p) == 0 {
+			continue
+		}
+		for _, pt := range p[0] {
+			b.MinLng = math.Min(b.MinLng, pt[0])
+			b.MaxLng = math.Max(b.MaxLng, pt[0])
+			b.MinLat = math.Min(b.MinLat, pt[1])
+			b.MaxLat = math.Max(b.MaxLat, pt[1])
+		}
+	}
+	return b
+}
+
+// Contains reports whether the point is inside any polygon of the shape.
+func (mp MultiPolygon) Contains(lat, lng float64) bool {
+	for _, p := range mp {
+		if p.Contains(lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the point is inside the outer ring and outside every hole.
+func (p Polygon) Contains(lat, lng float64) bool {
+	if len(p) == 0 || !p[0].contains(lat, lng) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.contains(lat, lng) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains uses the even-odd ray casting rule.
+func (r Ring) contains(lat, lng float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// DistanceToEdgeKm returns the approximate distance from the point to the nearest edge of the shape.
+func (mp MultiPolygon) DistanceToEdgeKm(lat, lng float64) float64 {
+	best := math.Inf(1)
+	kx := math.Cos(lat * math.Pi / 180.0)
+	for _, p := range mp {
+		for _, r := range p {
+			for i := 1; i < len(r); i++ {
+				best = math.Min(best, segmentDistanceDeg(lng*kx, lat, r[i-1][0]*kx, r[i-1][1], r[i][0]*kx, r[i][1]))
+			}
+		}
+	}
+	return best * kmPerDegree
+}
+
+// segmentDistanceDeg is the planar distance from (px, py) to the segment (ax, ay)-(bx, by).
+func segmentDistanceDeg(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/l))
+	}
+	return math.Hypot(px-(ax+t*dx), py-(ay+t*dy))
+}
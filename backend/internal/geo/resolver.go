@@ -0,0 +1,316 @@
+package geo
+
+import (
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// kmPerDegree is the length of one degree of latitude.
+const kmPerDegree = 111.195
+
+// coastalToleranceKm lets points just outside the simplified outlines (harbours, islands)
+// resolve to the nearest state.
+const coastalToleranceKm = 40.0
+
+// boundaryFiles holds the layers shipped with the binary: the simplified US state outlines
+// and, once built with cmd/countylayer and committed, the gzipped county layer.
+//
+//go:embed boundaries
+var boundaryFiles embed.FS
+
+const (
+	embeddedStates   = "boundaries/us_states.geojson"
+	embeddedCounties = "boundaries/us_counties.geojson.gz"
+)
+
+// Place is the administrative area a coordinate resolves to. US states fill the State
+// fields as well as the subdivision; other countries only have the Country and Subdivision.
+type Place struct {
+	CountryCode     string `json:"country,omitempty"` // ISO 3166-1 alpha-2
+	CountryName     string `json:"country_name,omitempty"`
+	SubdivisionCode string `json:"subdivision,omitempty"` // ISO 3166-2, e.g. "US-VA", "CA-QC"
+
+	StateCode  string `json:"state,omitempty"`
+	StateName  string `json:"state_name,omitempty"`
+	StateFIPS  string `json:"state_fips,omitempty"`
+	CountyName string `json:"county,omitempty"`
+	CountyFIPS string `json:"county_fips,omitempty"` // five-digit state+county code
+}
+
+// Boundary is one administrative area in a Layer.
+type Boundary struct {
+	Name  string
+	Code  string
+	FIPS  string
+	Shape MultiPolygon
+	bbox  BBox
+}
+
+// Layer is a set of non-overlapping boundaries of the same level (states, counties).
+type Layer struct {
+	Boundaries []Boundary
+}
+
+// Resolver maps coordinates to US states and, when a county layer is loaded, counties.
+// Optional country and subdivision layers resolve points outside the US.
+type Resolver struct {
+	mu           sync.RWMutex
+	states       *Layer
+	counties     *Layer
+	countries    *Layer
+	subdivisions *Layer
+}
+
+var (
+	defaultResolver     *Resolver
+	defaultResolverOnce sync.Once
+)
+
+// DefaultResolver returns the shared resolver backed by the embedded state outlines and,
+// when boundaries/us_counties.geojson.gz is embedded, counties. LoadStates and LoadCounties
+// replace them with files.
+func DefaultResolver() *Resolver {
+	defaultResolverOnce.Do(func() {
+		states, err := embeddedLayer(embeddedStates)
+		if err != nil {
+			panic(fmt.Sprintf("loading embedded state boundaries: %v", err))
+		}
+		counties, err := embeddedLayer(embeddedCounties)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			panic(fmt.Sprintf("loading embedded county boundaries: %v", err))
+		}
+		defaultResolver = NewResolver(states, counties)
+	})
+	return defaultResolver
+}
+
+func embeddedLayer(name string) (*Layer, error) {
+	f, err := boundaryFiles.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := readLayer(name, f)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLayer(content)
+}
+
+// NewResolver creates a resolver from a state layer and an optional county layer.
+func NewResolver(states, counties *Layer) *Resolver {
+	return &Resolver{states: states, counties: counties}
+}
+
+// LoadStates reads a state GeoJSON file, such as the Census cartographic boundary file
+// cb_2023_us_state_5m converted to GeoJSON, and uses it instead of the embedded outlines.
+func (r *Resolver) LoadStates(filename string) error {
+	return r.loadLayer(filename, &r.states)
+}
+
+// LoadCounties reads a county GeoJSON file (e.g. a Census cartographic boundary file
+// converted to GeoJSON, or the gzipped layer written by cmd/countylayer) and uses it for
+// county lookups.
+func (r *Resolver) LoadCounties(filename string) error {
+	return r.loadLayer(filename, &r.counties)
+}
+
+// HasCounties reports whether a county layer is loaded.
+func (r *Resolver) HasCounties() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.counties != nil
+}
+
+// LoadCountries reads a country GeoJSON file, such as Natural Earth admin-0, whose features
+// carry an ISO 3166-1 alpha-2 code, and uses it for points outside the US states.
+func (r *Resolver) LoadCountries(filename string) error {
+	return r.loadLayer(filename, &r.countries)
+}
+
+// LoadSubdivisions reads a first-level subdivision GeoJSON file, such as Natural Earth
+// admin-1, whose features carry an ISO 3166-2 code, for provinces and regions outside the US.
+func (r *Resolver) LoadSubdivisions(filename string) error {
+	return r.loadLayer(filename, &r.subdivisions)
+}
+
+func (r *Resolver) loadLayer(filename string, dst **Layer) error {
+	content, err := readLayerFile(filename)
+	if err != nil {
+		return err
+	}
+	layer, err := ParseLayer(content)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*dst = layer
+	return nil
+}
+
+func readLayerFile(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readLayer(filename, f)
+}
+
+// readLayer reads GeoJSON from r, decompressing it when name ends in .gz.
+func readLayer(name string, r io.Reader) ([]byte, error) {
+	if !strings.HasSuffix(name, ".gz") {
+		return io.ReadAll(r)
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// Resolve returns the state (and county, if loaded) containing the point, or with a
+// country layer loaded, the country and subdivision. Points inside a boundary win over
+// points within the coastal tolerance of one. The boolean is false when nothing matches.
+func (r *Resolver) Resolve(lat, lng float64) (Place, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if state, ok := r.states.find(lat, lng, 0); ok {
+		return r.usPlace(state, lat, lng), true
+	}
+	country, inCountry := r.countries.find(lat, lng, 0)
+	if !inCountry || country.Code == usCountryCode {
+		if state, ok := r.states.find(lat, lng, coastalToleranceKm); ok {
+			return r.usPlace(state, lat, lng), true
+		}
+	}
+	if !inCountry {
+		if country, inCountry = r.countries.find(lat, lng, coastalToleranceKm); !inCountry {
+			return Place{}, false
+		}
+	}
+
+	place := Place{CountryCode: country.Code, CountryName: country.Name}
+	if sub, ok := r.subdivisions.find(lat, lng, 0); ok && strings.HasPrefix(sub.Code, country.Code+"-") {
+		place.SubdivisionCode = sub.Code
+	}
+	return place, true
+}
+
+const usCountryCode = "US"
+
+// ResolveIn returns the place for a point known to be in country, a non-US ISO 3166-1
+// code. The US state layer is not consulted; the subdivision comes from the subdivision
+// layer when one is loaded and the point falls in one of country's subdivisions.
+func (r *Resolver) ResolveIn(country string, lat, lng float64) Place {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	place := Place{CountryCode: country}
+	if c, ok := r.countries.find(lat, lng, coastalToleranceKm); ok && c.Code == country {
+		place.CountryName = c.Name
+	}
+	if sub, ok := r.subdivisions.find(lat, lng, 0); ok && strings.HasPrefix(sub.Code, country+"-") {
+		place.SubdivisionCode = sub.Code
+	}
+	return place
+}
+
+func (r *Resolver) usPlace(state Boundary, lat, lng float64) Place {
+	place := Place{
+		CountryCode:     usCountryCode,
+		CountryName:     "United States",
+		SubdivisionCode: usCountryCode + "-" + state.Code,
+		StateCode:       state.Code,
+		StateName:       state.Name,
+		StateFIPS:       state.FIPS,
+	}
+	if county, ok := r.counties.find(lat, lng, 0); ok {
+		place.CountyName = county.Name
+		place.CountyFIPS = county.FIPS
+	}
+	return place
+}
+
+// ParseLayer reads boundaries from a GeoJSON FeatureCollection of Polygon/MultiPolygon features.
+// Recognised properties are name/NAME, code/STUSPS/ISO_A2_EH/ISO_A2/iso_3166_2, and fips/GEOID or
+// STATEFP+COUNTYFP or STATE+COUNTY.
+func ParseLayer(content []byte) (*Layer, error) {
+	var fc FeatureCollection
+	if err := json.Unmarshal(content, &fc); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	layer := &Layer{}
+	for i, f := range fc.Features {
+		shape, err := f.Geometry.MultiPolygon()
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		layer.Boundaries = append(layer.Boundaries, Boundary{
+			Name:  firstProp(f.Properties, "name", "NAME"),
+			Code:  firstProp(f.Properties, "code", "STUSPS", "ISO_A2_EH", "ISO_A2", "iso_a2", "iso_3166_2"),
+			FIPS:  fipsProp(f.Properties),
+			Shape: shape,
+			bbox:  shape.Bounds(),
+		})
+	}
+	return layer, nil
+}
+
+// find returns the boundary containing the point, or the nearest one within toleranceKm.
+func (l *Layer) find(lat, lng, toleranceKm float64) (Boundary, bool) {
+	if l == nil {
+		return Boundary{}, false
+	}
+	for _, b := range l.Boundaries {
+		if b.bbox.Contains(lat, lng) && b.Shape.Contains(lat, lng) {
+			return b, true
+		}
+	}
+	if toleranceKm <= 0 {
+		return Boundary{}, false
+	}
+	var (
+		nearest Boundary
+		found   bool
+		best    = toleranceKm
+	)
+	for _, b := range l.Boundaries {
+		if d := b.Shape.DistanceToEdgeKm(lat, lng); d <= best {
+			nearest, best, found = b, d, true
+		}
+	}
+	return nearest, found
+}
+
+func firstProp(props map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := props[k]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+func fipsProp(props map[string]interface{}) string {
+	if v := firstProp(props, "fips", "GEOID"); v != "" {
+		return v
+	}
+	if st, co := firstProp(props, "STATEFP"), firstProp(props, "COUNTYFP"); st != "" && co != "" {
+		return st + co
+	}
+	if st, co := firstProp(props, "STATE"), firstProp(props, "COUNTY"); st != "" && co != "" {
+		return st + co
+	}
+	return firstProp(props, "STATEFP", "STATE")
+}
@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loudounCounty is a rough box around Loudoun County, VA.
+const loudounCounty = `{"type":"FeatureCollection","features":[{"type":"Feature",
+	"properties":{"NAME":"Loudoun","STATEFP":"51","COUNTYFP":"107"},
+	"geometry":{"type":"Polygon","coordinates":[[[-77.96,38.85],[-77.32,38.85],[-77.32,39.33],[-77.96,39.33],[-77.96,38.85]]]}}]}`
+
+func TestResolve(t *testing.T) {
+	r := DefaultResolver()
+	tests := []struct {
+		name      string
+		lat, lng  float64
+		wantState string
+		wantOK    bool
+	}{
+		{"Ashburn", 39.0438, -77.4874, "VA", true},
+		{"Manhattan", 40.7411, -74.0032, "NY", true},
+		{"Secaucus", 40.7771, -74.0712, "NJ", true},
+		{"Pittsburg, KS", 37.4109, -94.7050, "KS", true},
+		{"Brookings, SD", 44.3114, -96.7984, "SD", true},
+		{"Honolulu", 21.3069, -157.8583, "HI", true},
+		{"mid-Atlantic", 35.0, -50.0, "", false},
+	}
+	for _, tt := range tests {
+		place, ok := r.Resolve(tt.lat, tt.lng)
+		if ok != tt.wantOK || place.StateCode != tt.wantState {
+			t.Errorf("%s: got %q, %v; want %q, %v", tt.name, place.StateCode, ok, tt.wantState, tt.wantOK)
+		}
+	}
+}
+
+func TestLoadCountiesGzipped(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "counties.geojson.gz")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte(loudounCounty))
+	zw.Close()
+	f.Close()
+
+	states, err := ParseLayer(mustRead(t, "boundaries/us_states.geojson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(states, nil)
+	if err := r.LoadCounties(filename); err != nil {
+		t.Fatal(err)
+	}
+	place, ok := r.Resolve(39.0438, -77.4874)
+	if !ok || place.CountyName != "Loudoun" || place.CountyFIPS != "51107" || place.StateFIPS != "51" {
+		t.Errorf("got %+v", place)
+	}
+	if place, _ := r.Resolve(37.5407, -77.4360); place.CountyName != "" {
+		t.Errorf("Richmond resolved to county %q", place.CountyName)
+	}
+}
+
+func TestDefaultResolverEmbedsCounties(t *testing.T) {
+	_, err := boundaryFiles.Open(embeddedCounties)
+	if embedded := err == nil; DefaultResolver().HasCounties() != embedded {
+		t.Errorf("HasCounties = %v with %s embedded: %v", DefaultResolver().HasCounties(), embeddedCounties, embedded)
+	}
+	if err != nil {
+		t.Skipf("%s not built; see cmd/countylayer", embeddedCounties)
+	}
+	if place, _ := DefaultResolver().Resolve(39.0438, -77.4874); place.CountyFIPS != "51107" {
+		t.Errorf("Ashburn resolved to county %q (%s), want Loudoun (51107)", place.CountyName, place.CountyFIPS)
+	}
+}
+
+func mustRead(t *testing.T, name string) []byte {
+	t.Helper()
+	content, err := boundaryFiles.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
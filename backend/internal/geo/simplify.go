@@ -0,0 +1,80 @@
+package geo
+
+import "math"
+
+// Simplify returns the shape with each ring reduced by the Douglas-Peucker algorithm, so no
+// dropped vertex is further than tolerance degrees from the simplified edge, and every
+// coordinate rounded to decimals places. Rings that collapse below a triangle are dropped,
+// and polygons whose outer ring collapses go with their holes.
+func (mp MultiPolygon) Simplify(tolerance float64, decimals int) MultiPolygon {
+	scale := math.Pow(10, float64(decimals))
+	var out MultiPolygon
+	for _, p := range mp {
+		var simplified Polygon
+		for i, r := range p {
+			s := r.simplify(tolerance, scale)
+			if len(s) < 4 {
+				if i == 0 {
+					break
+				}
+				continue
+			}
+			simplified = append(simplified, s)
+		}
+		if len(simplified) > 0 {
+			out = append(out, simplified)
+		}
+	}
+	return out
+}
+
+func (r Ring) simplify(tolerance, scale float64) Ring {
+	if len(r) < 4 {
+		return nil
+	}
+	keep := make([]bool, len(r))
+	keep[0], keep[len(r)-1] = true, true
+	// The ring is closed, so split it at the vertex furthest from the start to give the
+	// recursion a chord to measure against.
+	far, best := 0, -1.0
+	for i, pt := range r {
+		if d := math.Hypot(pt[0]-r[0][0], pt[1]-r[0][1]); d > best {
+			far, best = i, d
+		}
+	}
+	keep[far] = true
+	r.douglasPeucker(0, far, tolerance, keep)
+	r.douglasPeucker(far, len(r)-1, tolerance, keep)
+
+	var out Ring
+	for i, pt := range r {
+		if !keep[i] {
+			continue
+		}
+		pt = [2]float64{math.Round(pt[0]*scale) / scale, math.Round(pt[1]*scale) / scale}
+		if len(out) > 0 && out[len(out)-1] == pt {
+			continue
+		}
+		out = append(out, pt)
+	}
+	return out
+}
+
+func (r Ring) douglasPeucker(first, last int, tolerance float64, keep []bool) {
+	if last-first < 2 {
+		return
+	}
+	a, b := r[first], r[last]
+	index, dmax := 0, -1.0
+	for i := first + 1; i < last; i++ {
+		if d := segmentDistanceDeg(r[i][0], r[i][1], a[0], a[1], b[0], b[1]); d > dmax {
+			index, dmax = i, d
+		}
+	}
+	if dmax <= tolerance {
+		return
+	}
+	keep[index] = true
+	r.douglasPeucker(first, index, tolerance, keep)
+	r.douglasPeucker(index, last, tolerance, keep)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// AdminToken guards the admin endpoints; they are disabled while it is empty.
+var AdminToken string
+
+// authorizeAdmin checks for "Authorization: Bearer <AdminToken>".
+func authorizeAdmin(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) == 1
+}
+
+// ReloadDatasetsHandler handles POST /api/admin/reload-datasets[?region=..], re-reading the
+// site CSVs and reporting the row counts and diagnostics of the new snapshot.
+func ReloadDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorizeAdmin(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	registry, err := siteRegistry(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+	if err := registry.Reload(); err != nil {
+		http.Error(w, "Error reloading datasets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	snapshot, err := registry.Snapshot()
+	if err != nil {
+		http.Error(w, "Error reloading datasets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"loaded_at":            snapshot.LoadedAt,
+		"possible_locations":   len(snapshot.PossibleLocations.Rows),
+		"existing_datacenters": len(snapshot.ExistingDatacenters.Rows),
+		"possible_diagnostics": snapshot.PossibleLocations.Diagnostics,
+		"existing_diagnostics": snapshot.ExistingDatacenters.Diagnostics,
+	})
+}
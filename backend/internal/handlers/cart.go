@@ -8,6 +8,7 @@ import (
 
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
 )
 
 // AddToCartRequest is the expected JSON payload for adding an item.
@@ -78,7 +79,15 @@ func AddToCartHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetCartHandler handles GET /cart?username=...
+// cartCollection is a cart as GeoJSON, with the cart's own fields as foreign members.
+type cartCollection struct {
+	geo.FeatureCollection
+	Username  string  `json:"username"`
+	MoneyLeft float64 `json:"money_left"`
+}
+
+// GetCartHandler handles GET /cart?username=..., as GeoJSON when asked for with
+// ?format=geojson or Accept: application/geo+json.
 func GetCartHandler(w http.ResponseWriter, r *http.Request) {
 	addCORSHeaders(w)
 	fmt.Println("GetCartHandler called")
@@ -100,6 +109,15 @@ func GetCartHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Cart not found", http.StatusNotFound)
 		return
 	}
+	w.Header().Add("Vary", "Accept")
+	if wantsGeoJSON(r) {
+		writeGeoJSON(w, cartCollection{
+			FeatureCollection: locationCollection(c.Items),
+			Username:          c.Username,
+			MoneyLeft:         c.MoneyLeft,
+		})
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(c)
 }
@@ -139,6 +157,52 @@ func DeleteCartItemHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// UpdateCartItemRequest is the expected JSON payload for updating an item.
+type UpdateCartItemRequest struct {
+	Schedule *data.OperatingSchedule `json:"schedule"` // null clears it
+}
+
+// UpdateCartItemHandler handles PUT /cart/item?username=alice&index=0 with an
+// UpdateCartItemRequest body, setting when the item is commissioned and decommissioned.
+func UpdateCartItemHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	username := r.URL.Query().Get("username")
+	indexStr := r.URL.Query().Get("index")
+	if username == "" || indexStr == "" {
+		http.Error(w, "username and index parameters are required", http.StatusBadRequest)
+		return
+	}
+	index, err := strconv.Atoi(indexStr)
+	if err != nil {
+		http.Error(w, "Invalid index value", http.StatusBadRequest)
+		return
+	}
+	var req UpdateCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := cart.SetItemSchedule(username, index, req.Schedule); err != nil {
+		http.Error(w, fmt.Sprintf("Error updating cart item: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": "Cart item updated",
+	})
+}
+
 // DeleteCartHandler handles DELETE /cart?username=alice
 func DeleteCartHandler(w http.ResponseWriter, r *http.Request) {
 	addCORSHeaders(w)
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+)
+
+const (
+	maxComparePortfolios = 10
+	maxPortfolioItems    = 200
+	maxCompareBodySize   = 4 << 20
+)
+
+// Portfolio is one buildout to compare: its own items, or a snapshot of Username's cart.
+type Portfolio struct {
+	Name     string                    `json:"name"`
+	Username string                    `json:"username,omitempty"`
+	Items    []data.DatacenterLocation `json:"items,omitempty"`
+}
+
+// CompareRequest is the body of POST /api/simulation/compare.
+type CompareRequest struct {
+	Portfolios []Portfolio `json:"portfolios"`
+}
+
+// PortfolioSummary is a portfolio's outcome over the simulation, or in a PortfolioDelta,
+// the difference between two outcomes.
+type PortfolioSummary struct {
+	Facilities           int     `json:"facilities"`
+	TotalTimeToEnd       int     `json:"total_time_to_end"`
+	CumulativeEmissions  float64 `json:"cumulative_emissions"`   // tonnes CO2e
+	CumulativeWaterUsage float64 `json:"cumulative_water_usage"` // gallons
+	PeakContribution     float64 `json:"peak_contribution"`      // extra °C
+	FinalTemperature     float64 `json:"final_temperature"`      // °C in the last year
+}
+
+// PortfolioProjection is the simulation of one portfolio.
+type PortfolioProjection struct {
+	Name        string                 `json:"name"`
+	Summary     PortfolioSummary       `json:"summary"`
+	Totals      []YearContribution     `json:"totals"` // all facilities, by year
+	Projections []ClimateProjection    `json:"projections"`
+	Facilities  []FacilityContribution `json:"facilities"`
+}
+
+// YearDelta is a portfolio's difference from the reference portfolio in one year.
+type YearDelta struct {
+	Year                   int     `json:"year"`
+	Emissions              float64 `json:"emissions"`   // tonnes CO2e
+	WaterUsage             float64 `json:"water_usage"` // gallons
+	DataCenterContribution float64 `json:"data_center_contribution"`
+	TotalTemperature       float64 `json:"total_temperature"`
+	Survivability          int     `json:"survivability"`
+}
+
+// PortfolioDelta is a portfolio minus the reference portfolio, the first in the request.
+type PortfolioDelta struct {
+	Name      string           `json:"name"`
+	Reference string           `json:"reference"`
+	Summary   PortfolioSummary `json:"summary"`
+	Series    []YearDelta      `json:"series"`
+}
+
+// ComparisonResponse holds aligned projections of every portfolio, from startYear to
+// endYear, against the same baseline.
+type ComparisonResponse struct {
+	Scenario                    ScenarioInfo          `json:"scenario"`
+	ResponseModel               string                `json:"response_model"`
+	ClimateResponse             *data.ClimateResponse `json:"climate_response,omitempty"` // tcre model only
+	Grid                        string                `json:"grid"`
+	WithoutDataCenters          []ClimateProjection   `json:"without_data_centers"`
+	TimeToEndWithoutDataCenters int                   `json:"time_to_end_without_data_centers"`
+	Portfolios                  []PortfolioProjection `json:"portfolios"`
+	Deltas                      []PortfolioDelta      `json:"deltas"`
+}
+
+// CompareSimulationHandler handles POST /api/simulation/compare with two or more
+// portfolios, each named and given as items or as the username of a cart to snapshot. It
+// takes the query parameters of /api/simulation, and reports every later portfolio's
+// differences from the first.
+func CompareSimulationHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts, err := simulationOptionsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req CompareRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCompareBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	portfolios, err := resolvePortfolios(req.Portfolios)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, nearby, err := loadSites(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+
+	without, timeToEndWithout := projectClimate(opts.scenario, nil)
+	resp := ComparisonResponse{
+		Scenario:                    opts.scenarioInfo(),
+		ResponseModel:               opts.model,
+		ClimateResponse:             opts.climateResponse(),
+		Grid:                        opts.grid,
+		WithoutDataCenters:          without,
+		TimeToEndWithoutDataCenters: timeToEndWithout,
+	}
+	for _, p := range portfolios {
+		projection, err := simulatePortfolio(p, nearby, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp.Portfolios = append(resp.Portfolios, projection)
+	}
+	reference := resp.Portfolios[0]
+	for _, p := range resp.Portfolios[1:] {
+		resp.Deltas = append(resp.Deltas, portfolioDelta(p, reference))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// resolvePortfolios checks the portfolios and copies in the items of cart snapshots.
+// Unnamed portfolios are named after their cart's user or their position.
+func resolvePortfolios(portfolios []Portfolio) ([]Portfolio, error) {
+	if len(portfolios) < 2 || len(portfolios) > maxComparePortfolios {
+		return nil, fmt.Errorf("compare between 2 and %d portfolios", maxComparePortfolios)
+	}
+	resolved := make([]Portfolio, len(portfolios))
+	names := make(map[string]bool, len(portfolios))
+	for i, p := range portfolios {
+		if p.Username != "" {
+			if len(p.Items) > 0 {
+				return nil, fmt.Errorf("portfolio %d has both a username and items", i+1)
+			}
+			c, ok := cart.GetCart(p.Username)
+			if !ok {
+				return nil, fmt.Errorf("portfolio %d: no cart for user %q", i+1, p.Username)
+			}
+			p.Items = append([]data.DatacenterLocation(nil), c.Items...)
+			if p.Name == "" {
+				p.Name = p.Username
+			}
+		}
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("portfolio %d", i+1)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("portfolio name %q is used twice", p.Name)
+		}
+		names[p.Name] = true
+		if len(p.Items) > maxPortfolioItems {
+			return nil, fmt.Errorf("portfolio %q has more than %d items", p.Name, maxPortfolioItems)
+		}
+		items := make([]data.DatacenterLocation, len(p.Items))
+		for j, item := range p.Items {
+			var err error
+			if items[j], err = portfolioItem(item); err != nil {
+				return nil, fmt.Errorf("portfolio %q item %d: %w", p.Name, j+1, err)
+			}
+		}
+		p.Items = items
+		resolved[i] = p
+	}
+	return resolved, nil
+}
+
+// portfolioItem keeps what a client may say about a planned site: where and what it is,
+// its facility, filled in from its tier, and its schedule. Metrics sent with it are
+// dropped; the simulation scores the site itself.
+func portfolioItem(item data.DatacenterLocation) (data.DatacenterLocation, error) {
+	if !data.ValidCoordinate(item.Latitude, item.Longitude) {
+		return item, fmt.Errorf("coordinates out of range")
+	}
+	site := data.DatacenterLocation{
+		Latitude:           item.Latitude,
+		Longitude:          item.Longitude,
+		Name:               item.Name,
+		LandPrice:          item.LandPrice,
+		Electricity:        item.Electricity,
+		Notes:              item.Notes,
+		Tags:               item.Tags,
+		Hyperscalers:       item.Hyperscalers,
+		SubmarineCable:     item.SubmarineCable,
+		GovernmentPresence: item.GovernmentPresence,
+		Country:            item.Country,
+		Subdivision:        item.Subdivision,
+		State:              item.State,
+		Schedule:           item.Schedule,
+	}
+	if item.Facility != nil {
+		facility, err := item.Facility.WithDefaults()
+		if err != nil {
+			return item, err
+		}
+		site.Facility = &facility
+	}
+	if site.Schedule != nil {
+		if err := site.Schedule.Validate(); err != nil {
+			return item, err
+		}
+	}
+	return site, nil
+}
+
+// simulatePortfolio projects one portfolio with the selected grid variant.
+func simulatePortfolio(p Portfolio, nearby *data.SpatialIndex, opts simulationOptions) (PortfolioProjection, error) {
+	items, err := scoreCartItems(p.Items, nearby)
+	if err != nil {
+		return PortfolioProjection{}, fmt.Errorf("portfolio %q: %w", p.Name, err)
+	}
+	facilities := calcFacilityContributions(items, opts.model, opts.response, opts.grid)
+	projections, timeToEnd := projectClimate(opts.scenario, facilities)
+	totals := sumGridVariant(opts.grid, facilities, timeToEnd)
+
+	summary := PortfolioSummary{
+		Facilities:           len(facilities),
+		TotalTimeToEnd:       timeToEnd,
+		CumulativeEmissions:  totals.CumulativeEmissions,
+		CumulativeWaterUsage: totals.CumulativeWaterUsage,
+		FinalTemperature:     projections[len(projections)-1].TotalTemperature,
+	}
+	for _, y := range totals.Series {
+		summary.PeakContribution = math.Max(summary.PeakContribution, y.Contribution)
+	}
+	return PortfolioProjection{
+		Name:        p.Name,
+		Summary:     summary,
+		Totals:      totals.Series,
+		Projections: projections,
+		Facilities:  facilities,
+	}, nil
+}
+
+// portfolioDelta subtracts the reference from p, year by year.
+func portfolioDelta(p, reference PortfolioProjection) PortfolioDelta {
+	a, b := p.Summary, reference.Summary
+	d := PortfolioDelta{
+		Name:      p.Name,
+		Reference: reference.Name,
+		Summary: PortfolioSummary{
+			Facilities:           a.Facilities - b.Facilities,
+			TotalTimeToEnd:       a.TotalTimeToEnd - b.TotalTimeToEnd,
+			CumulativeEmissions:  a.CumulativeEmissions - b.CumulativeEmissions,
+			CumulativeWaterUsage: a.CumulativeWaterUsage - b.CumulativeWaterUsage,
+			PeakContribution:     a.PeakContribution - b.PeakContribution,
+			FinalTemperature:     a.FinalTemperature - b.FinalTemperature,
+		},
+		Series: make([]YearDelta, len(p.Projections)),
+	}
+	for i := range p.Projections {
+		pa, pb := p.Projections[i], reference.Projections[i]
+		d.Series[i] = YearDelta{
+			Year:                   pa.Year,
+			Emissions:              p.Totals[i].Emissions - reference.Totals[i].Emissions,
+			WaterUsage:             p.Totals[i].WaterUsage - reference.Totals[i].WaterUsage,
+			DataCenterContribution: pa.DataCenterContribution - pb.DataCenterContribution,
+			TotalTemperature:       pa.TotalTemperature - pb.TotalTemperature,
+			Survivability:          pa.Survivability - pb.Survivability,
+		}
+	}
+	return d
+}
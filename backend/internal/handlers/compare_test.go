@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+)
+
+func compare(t *testing.T, query string, req CompareRequest) (*httptest.ResponseRecorder, ComparisonResponse) {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp ComparisonResponse
+	rec := serveJSON(t, CompareSimulationHandler, httptest.NewRequest(http.MethodPost, "/api/simulation/compare?"+query, bytes.NewReader(body)), &resp)
+	return rec, resp
+}
+
+func TestCompareSimulation(t *testing.T) {
+	withCart(t, "compare-cart", ashburn(nil))
+	inflated := ashburn(nil)
+	inflated.CarbonImpact = 1e12
+	inflated.WaterUsage = 1e15
+	eco := ashburn(nil)
+	eco.Facility = &data.FacilityProfile{Tier: "Eco"}
+
+	rec, resp := compare(t, "grid=frozen", CompareRequest{Portfolios: []Portfolio{
+		{Username: "compare-cart"},
+		{Name: "client metrics", Items: []data.DatacenterLocation{inflated}},
+		{Name: "eco", Items: []data.DatacenterLocation{eco}},
+		{Name: "doubled", Items: []data.DatacenterLocation{ashburn(nil), ashburn(nil)}},
+	}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.Portfolios) != 4 || len(resp.Deltas) != 3 {
+		t.Fatalf("got %d portfolios and %d deltas", len(resp.Portfolios), len(resp.Deltas))
+	}
+	reference := resp.Portfolios[0]
+	if reference.Name != "compare-cart" || reference.Summary.Facilities != 1 {
+		t.Errorf("cart portfolio %q with %d facilities", reference.Name, reference.Summary.Facilities)
+	}
+
+	// Metrics sent with an item are replaced by the server's scoring.
+	if got, want := resp.Portfolios[1].Summary, reference.Summary; got.CumulativeEmissions != want.CumulativeEmissions || got.CumulativeWaterUsage != want.CumulativeWaterUsage {
+		t.Errorf("client metrics kept: %+v, want %+v", got, want)
+	}
+	if d := resp.Deltas[0].Summary; d.CumulativeEmissions != 0 || d.FinalTemperature != 0 {
+		t.Errorf("identical portfolios differ: %+v", d)
+	}
+	if !(resp.Portfolios[2].Summary.CumulativeEmissions < reference.Summary.CumulativeEmissions) {
+		t.Errorf("Eco tier emits %g, not less than Standard %g", resp.Portfolios[2].Summary.CumulativeEmissions, reference.Summary.CumulativeEmissions)
+	}
+
+	doubled := resp.Deltas[2]
+	if doubled.Name != "doubled" || doubled.Reference != "compare-cart" {
+		t.Errorf("delta %q against %q", doubled.Name, doubled.Reference)
+	}
+	if got, want := doubled.Summary.CumulativeEmissions, reference.Summary.CumulativeEmissions; math.Abs(got-want) > 1e-9*want {
+		t.Errorf("second site adds %g t, want %g", got, want)
+	}
+	if len(doubled.Series) != endYear-startYear+1 {
+		t.Fatalf("delta series has %d years", len(doubled.Series))
+	}
+	for i, y := range doubled.Series {
+		want := resp.Portfolios[3].Totals[i].Emissions - reference.Totals[i].Emissions
+		if math.Abs(y.Emissions-want) > 1e-6 {
+			t.Errorf("%d: delta emissions %g, want %g", y.Year, y.Emissions, want)
+			break
+		}
+	}
+	for i, p := range resp.WithoutDataCenters {
+		if p.BaselineTemperature != reference.Projections[i].BaselineTemperature {
+			t.Errorf("%d: portfolios and baseline use different scenarios", p.Year)
+			break
+		}
+	}
+}
+
+func TestCompareSimulationRejects(t *testing.T) {
+	site := ashburn(nil)
+	offMap := ashburn(nil)
+	offMap.Latitude = 120
+	tests := []struct {
+		name string
+		req  CompareRequest
+	}{
+		{"one portfolio", CompareRequest{Portfolios: []Portfolio{{Items: []data.DatacenterLocation{site}}}}},
+		{"duplicate names", CompareRequest{Portfolios: []Portfolio{{Name: "a"}, {Name: "a"}}}},
+		{"unknown cart", CompareRequest{Portfolios: []Portfolio{{Username: "compare-nobody"}, {Name: "b"}}}},
+		{"coordinates out of range", CompareRequest{Portfolios: []Portfolio{{Name: "a"}, {Name: "b", Items: []data.DatacenterLocation{offMap}}}}},
+		{"unknown tier", CompareRequest{Portfolios: []Portfolio{{Name: "a"}, {Name: "b", Items: []data.DatacenterLocation{{
+			Latitude: 39, Longitude: -77.5, Facility: &data.FacilityProfile{Tier: "Platinum"},
+		}}}}}},
+	}
+	for _, tt := range tests {
+		if rec, _ := compare(t, "", tt.req); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if _, err := portfolioItem(data.DatacenterLocation{Latitude: math.NaN(), Longitude: -77}); err == nil {
+		t.Error("NaN latitude accepted")
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+)
+
+// maxDatasetUploadSize bounds an uploaded site file.
+const maxDatasetUploadSize = 32 << 20
+
+// DatasetsHandler handles GET /api/datasets, listing the uploaded candidate-site datasets,
+// and POST /api/datasets, a multipart form with the file, the dataset name, an optional
+// format (geojson, kml, kmz or shapefile; guessed from the file name otherwise) and an
+// optional mapping, a JSON data.ColumnMapping. Uploads create or replace a dataset on disk,
+// so they need the admin token. Other endpoints use a dataset with ?dataset=name.
+// Datasets belong to the country in ?region=, the US by default.
+func DatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	registry, err := siteRegistry(r)
+	if err != nil && r.Method != http.MethodOptions {
+		writeSitesError(w, err)
+		return
+	}
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(registry.NamedDatasets())
+	case http.MethodPost:
+		if !authorizeAdmin(r) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxDatasetUploadSize)
+		if err := r.ParseMultipartForm(maxDatasetUploadSize); err != nil {
+			http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := r.FormValue("name")
+		if name == "" {
+			http.Error(w, "Missing dataset name", http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		format := data.ImportFormat(r.FormValue("format"))
+		if format == "" {
+			var ok bool
+			if format, ok = data.DetectImportFormat(header.Filename); !ok {
+				http.Error(w, "Cannot tell the format of "+header.Filename+"; set format", http.StatusBadRequest)
+				return
+			}
+		}
+		var mapping data.ColumnMapping
+		if m := r.FormValue("mapping"); m != "" {
+			if err := json.Unmarshal([]byte(m), &mapping); err != nil {
+				http.Error(w, "Invalid mapping: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ds, err := data.ImportSites(header.Filename, format, content, mapping, registry.Country())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(ds.Rows) == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":       "no sites could be imported",
+				"diagnostics": ds.Diagnostics,
+			})
+			return
+		}
+		if err := registry.SaveNamed(name, ds); err != nil {
+			http.Error(w, "Error saving dataset: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":        name,
+			"format":      format,
+			"sites":       len(ds.Rows),
+			"diagnostics": ds.Diagnostics,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// RegionsHandler handles GET /api/regions, listing the countries with loaded site datasets.
+// Their codes are the values of the region parameter of the other endpoints.
+func RegionsHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data.Regions())
+}
@@ -4,93 +4,128 @@ import (
 	"math"
 
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
 )
 
-// CalculateResearchBasedMetrics applies your research-based env. calculations
-func CalculateResearchBasedMetrics(loc *data.DatacenterLocation, allDatacenters []data.DatacenterLocation) {
-	envData := data.GetEnvironmentalData(loc)
+// CalculateResearchBasedMetrics applies your research-based env. calculations for the given
+// facility, scoring with profile. Neighbouring sites for density and water competition come from nearby.
+func CalculateResearchBasedMetrics(loc *data.DatacenterLocation, nearby *data.SpatialIndex, facility data.FacilityProfile, profile scoring.Profile) {
+	envData := data.GetEnvironmentalData(loc, nearby)
 
-	// 1. Calculate PUE (Power Usage Effectiveness) based on climate
-	pue := calculateLocationBasedPUE(envData.AmbientTemperature, envData.DatacenterDensity)
+	// PUE (Power Usage Effectiveness) and WUE from the cooling model over monthly climate normals
+	cooling := calculateCooling(facility, envData.MonthlyClimate, envData.DatacenterDensity)
+	impact := calculateImpact(envData, facility, profile, cooling.AnnualPUE, cooling.AnnualWUE)
 
-	// 2. Some constants
-	const (
-		itLoadMW        = 15.0
-		hoursPerYear    = 8760.0
-		waterUseLPerKWh = 1.8
-		landUseHectares = 12.0
-	)
+	// Assign values
+	loc.Facility = &facility
+	loc.Cooling = &cooling
+	loc.EcoScore = int(impact.ecoScore)
+	loc.CarbonImpact = impact.carbonImpact
+	loc.TempIncrease = impact.tempIncrease
+	loc.WaterUsage = impact.waterUsage
+	loc.CompoundedTempIncrease = impact.compoundedTempIncrease
+	loc.WaterCompetition = impact.waterCompetition
+	loc.DatacenterDensity = envData.DatacenterDensity
+	if envData.Place.CountryCode != "" {
+		loc.Country = envData.Place.CountryCode
+		loc.Subdivision = envData.Place.SubdivisionCode
+	}
+	loc.State = envData.Place.StateCode
+	loc.StateFIPS = envData.Place.StateFIPS
+	loc.County = envData.Place.CountyName
+	loc.CountyFIPS = envData.Place.CountyFIPS
+	loc.RenewableAccess = int(envData.RenewablePenetration)
+	loc.DisasterRisk = envData.NaturalDisasterRisk
+
+	// Density impact score
+	if envData.DatacenterDensity == 0 {
+		loc.DensityImpactScore = 0
+	} else {
+		loc.DensityImpactScore = int(math.Min(100, 20*math.Log1p(float64(envData.DatacenterDensity))))
+	}
+}
+
+// impactMetrics are the model outputs that depend on uncertain inputs.
+type impactMetrics struct {
+	ecoScore               float64 // 1-100
+	carbonImpact           float64 // metric tons CO2e/year
+	tempIncrease           float64 // °C
+	waterUsage             float64 // gallons/year, including competition
+	compoundedTempIncrease float64
+	waterCompetition       float64
+
+	components ecoComponents // eco score inputs, for re-scoring
+}
 
-	// 3. Calculate total energy usage (MWh/year)
+// calculateImpact runs the model for one set of inputs, scoring with profile.
+func calculateImpact(envData data.EnvironmentalData, facility data.FacilityProfile, profile scoring.Profile, pue, wue float64) impactMetrics {
+	// 1. Some constants
+	const hoursPerYear = 8760.0
+	itLoadMW := facility.ITLoadMW
+
+	// 2. Calculate total energy usage (MWh/year)
 	totalEnergyMWh := itLoadMW * pue * hoursPerYear
 
-	// 4. Calculate carbon emissions using regional grid intensity (kg CO2e/year)
-	carbonEmissions := totalEnergyMWh * 1000 * envData.GridEmissionsIntensity
+	// 3. Calculate carbon emissions (kg CO2e/year): grid share at regional intensity plus backup generation
+	gridEnergyMWh := totalEnergyMWh * (1 - facility.RenewableShare())
+	backupEnergyMWh := itLoadMW * facility.BackupHours()
+	carbonEmissions := gridEnergyMWh*1000*envData.GridEmissionsIntensity +
+		backupEnergyMWh*1000*facility.BackupEmissionsIntensity()
 
-	// 5. Calculate water consumption
-	waterConsumption := totalEnergyMWh * 1000 * waterUseLPerKWh
+	// 4. Calculate water consumption (WUE is per kWh of IT energy)
+	waterConsumption := itLoadMW * hoursPerYear * 1000 * wue
 	waterImpact := waterConsumption * envData.WaterScarcityIndex
 
-	// 6. Temperature impact
+	// 5. Temperature impact
 	heatRejection := itLoadMW * (1.0 - (1.0 / pue)) * 3.412
 	tempImpact := calculateTemperatureImpact(heatRejection, envData.DatacenterDensity, envData.AmbientTemperature)
 
-	// 7. Land use impact
-	landImpact := landUseHectares * envData.LandUseChangeImpact * envData.BiodiversitySensitivity
+	// 6. Land use impact
+	landImpact := facility.LandFootprintHectares * envData.LandUseChangeImpact * envData.BiodiversitySensitivity
 
-	// 8. Overall Eco Score
-	ecoScore := calcEcoScore(carbonEmissions, waterImpact, tempImpact, landImpact, envData.SocioeconomicImpact)
+	// 7. Overall Eco Score
+	components := ecoComponents{carbonEmissions, waterImpact, tempImpact, landImpact, envData.SocioeconomicImpact}
+	ecoScore := calcEcoScore(components, profile)
 	if ecoScore < 1 {
 		ecoScore = 1
 	} else if ecoScore > 100 {
 		ecoScore = 100
 	}
 
-	// Assign values
-	loc.EcoScore = int(ecoScore)
-	loc.CarbonImpact = carbonEmissions / 1000 // metric tons
-	loc.TempIncrease = tempImpact
-	loc.WaterUsage = waterConsumption / 3.785 // gallons
-	loc.DatacenterDensity = envData.DatacenterDensity
-	loc.RenewableAccess = int(envData.RenewablePenetration)
+	m := impactMetrics{
+		ecoScore:               ecoScore,
+		components:             components,
+		carbonImpact:           carbonEmissions / 1000, // metric tons
+		tempIncrease:           tempImpact,
+		waterUsage:             waterConsumption / 3.785, // gallons
+		compoundedTempIncrease: tempImpact,
+		waterCompetition:       1.0,
+	}
 
-	// Calculate compound effects
+	// 8. Compound effects
 	if envData.DatacenterDensity > 0 {
 		densityFactor := math.Log1p(float64(envData.DatacenterDensity)) / math.Log1p(10.0)
-		loc.CompoundedTempIncrease = tempImpact * (1.0 + densityFactor)
-		loc.WaterCompetition = 1.0 + densityFactor
-		loc.WaterUsage *= loc.WaterCompetition
-	} else {
-		loc.CompoundedTempIncrease = tempImpact
-		loc.WaterCompetition = 1.0
-	}
-
-	// Density impact score
-	if envData.DatacenterDensity == 0 {
-		loc.DensityImpactScore = 0
-	} else {
-		loc.DensityImpactScore = int(math.Min(100, 20*math.Log1p(float64(envData.DatacenterDensity))))
+		m.compoundedTempIncrease = tempImpact * (1.0 + densityFactor)
+		m.waterCompetition = 1.0 + densityFactor
+		m.waterUsage *= m.waterCompetition
 	}
+	return m
 }
 
-func calculateLocationBasedPUE(averageTemp float64, density int) float64 {
-	var basePUE float64
-
-	if averageTemp < 10 {
-		basePUE = 1.15 + (averageTemp+10)*0.005
-	} else if averageTemp < 18 {
-		basePUE = 1.2 + (averageTemp-10)*0.01
-	} else if averageTemp < 24 {
-		basePUE = 1.3 + (averageTemp-18)*0.025
-	} else {
-		basePUE = 1.45 + (averageTemp-24)*0.04
-	}
+// calculateCooling runs the facility's cooling model and applies any design PUE/WUE.
+// Neighbouring datacenters warm the intake air, adding a little overhead every month.
+func calculateCooling(facility data.FacilityProfile, normals []data.ClimateNormal, density int) data.CoolingPerformance {
+	cooling := data.ModelCooling(facility.Cooling, normals)
+	cooling.Scale(facility.DesignPUE, facility.WUE)
 
 	if density > 0 {
 		densityEffect := 0.01 * math.Min(0.5, math.Log10(float64(density))/2)
-		basePUE += densityEffect
+		for i := range cooling.Monthly {
+			cooling.Monthly[i].PUE += densityEffect
+		}
+		cooling.AnnualPUE += densityEffect
 	}
-	return basePUE
+	return cooling
 }
 
 func calculateTemperatureImpact(heatRejection float64, density int, ambientTemp float64) float64 {
@@ -111,30 +146,36 @@ func calculateTemperatureImpact(heatRejection float64, density int, ambientTemp
 	return baseIncrease * densityMultiplier * climateFactor
 }
 
-func calcEcoScore(carbonEmissions, waterImpact, tempImpact, landImpact, socioImpact float64) float64 {
-	const (
-		carbonNorm = 5000000.0
-		waterNorm  = 50000000.0
-		tempNorm   = 2.0
-		landNorm   = 10.0
-
-		weightCarbon = 0.40
-		weightWater  = 0.25
-		weightTemp   = 0.20
-		weightLand   = 0.10
-		weightSocial = 0.05
-	)
-
-	normCarbon := carbonEmissions / carbonNorm
-	normWater := waterImpact / waterNorm
-	normTemp := tempImpact / tempNorm
-	normLand := landImpact / landNorm
-
-	envImpact := (normCarbon * weightCarbon) +
-		(normWater * weightWater) +
-		(normTemp * weightTemp) +
-		(normLand * weightLand) +
-		(socioImpact * weightSocial)
+// ecoWeightNames lists the weights in the order of ecoComponents.
+var ecoWeightNames = [numEcoComponents]string{"carbon", "water", "temp", "land", "social"}
+
+const numEcoComponents = 5
+
+// ecoComponents are the raw carbon, water, temp, land and social impacts.
+type ecoComponents [numEcoComponents]float64
+
+func weightValues(w scoring.Weights) [numEcoComponents]float64 {
+	return [numEcoComponents]float64{w.Carbon, w.Water, w.Temp, w.Land, w.Social}
+}
+
+func weightsFrom(v [numEcoComponents]float64) scoring.Weights {
+	return scoring.Weights{Carbon: v[0], Water: v[1], Temp: v[2], Land: v[3], Social: v[4]}
+}
 
+// calcEcoScore is 100 minus the weighted, normalised impact, before clamping to 1-100.
+func calcEcoScore(c ecoComponents, p scoring.Profile) float64 {
+	n := p.Normalisers
+	normalised := [numEcoComponents]float64{
+		c[0] / n.Carbon,
+		c[1] / n.Water,
+		c[2] / n.Temp,
+		c[3] / n.Land,
+		c[4],
+	}
+
+	envImpact := 0.0
+	for i, weight := range weightValues(p.Weights) {
+		envImpact += normalised[i] * weight
+	}
 	return 100 - (envImpact * 100)
 }
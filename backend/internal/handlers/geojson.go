@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/geo"
+)
+
+const geoJSONContentType = "application/geo+json"
+
+// wantsGeoJSON reports whether the client asked for GeoJSON with ?format=geojson or
+// an Accept header listing application/geo+json.
+func wantsGeoJSON(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "geojson") {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == geoJSONContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// locationFeature is a Point feature whose properties are the location's JSON fields,
+// less the coordinates.
+func locationFeature(loc *data.DatacenterLocation) geo.Feature {
+	props := make(map[string]interface{})
+	if b, err := json.Marshal(loc); err == nil {
+		json.Unmarshal(b, &props)
+	}
+	delete(props, "latitude")
+	delete(props, "longitude")
+	return geo.PointFeature(loc.Latitude, loc.Longitude, props)
+}
+
+// locationCollection builds a FeatureCollection with one feature per location.
+func locationCollection(locations []data.DatacenterLocation) geo.FeatureCollection {
+	fc := geo.NewFeatureCollection()
+	for i := range locations {
+		fc.Features = append(fc.Features, locationFeature(&locations[i]))
+	}
+	return fc
+}
+
+// writeGeoJSON encodes v, a FeatureCollection or one with foreign members, as GeoJSON.
+func writeGeoJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", geoJSONContentType)
+	json.NewEncoder(w).Encode(v)
+}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"math"
 	"net/http"
 	"strconv"
+	"sync"
 
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/session"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/user"
@@ -184,7 +186,8 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// AllDataCentersHandler handles GET /alldatacenters
+// AllDataCentersHandler handles GET /alldatacenters[?region=..], as GeoJSON when asked for
+// with ?format=geojson or Accept: application/geo+json.
 func AllDataCentersHandler(w http.ResponseWriter, r *http.Request) {
 	addCORSHeaders(w)
 	if r.Method == http.MethodOptions {
@@ -192,11 +195,26 @@ func AllDataCentersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dataCenters, err := data.ReadAllDataCenters("us_datacenters.csv")
+	registry, err := siteRegistry(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+	snapshot, err := registry.Snapshot()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to open file: %v", err), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Add("Vary", "Accept")
+	if wantsGeoJSON(r) {
+		writeGeoJSON(w, locationCollection(snapshot.ExistingDatacenters.Rows))
+		return
+	}
+
+	dataCenters := make([]data.DataCenter, 0, len(snapshot.ExistingDatacenters.Rows))
+	for _, dc := range snapshot.ExistingDatacenters.Rows {
+		dataCenters = append(dataCenters, data.DataCenter{Name: dc.Name, Latitude: dc.Latitude, Longitude: dc.Longitude})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(dataCenters); err != nil {
@@ -205,7 +223,9 @@ func AllDataCentersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// PossibleDataCenterHandler handles GET /api/possible-datacenters
+// PossibleDataCenterHandler handles GET /api/possible-datacenters[?region=..&dataset=..]. GeoJSON responses
+// (?format=geojson or Accept: application/geo+json) carry each location's metrics, scored
+// with the optional tier, profile and username parameters.
 func PossibleDataCenterHandler(w http.ResponseWriter, r *http.Request) {
 	addCORSHeaders(w)
 	if r.Method == http.MethodOptions {
@@ -217,15 +237,42 @@ func PossibleDataCenterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	locations, err := data.ReadDatacenterLocations("us_possible_locations.csv")
+	w.Header().Add("Vary", "Accept")
+	if wantsGeoJSON(r) {
+		// GeoJSON clients get every location scored, like /api/sites/rank.
+		profile, err := scoringProfileFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		facility, ok := facilityFromQuery(r)
+		if !ok {
+			http.Error(w, "Unknown facility tier", http.StatusBadRequest)
+			return
+		}
+		locations, nearby, err := loadSites(r)
+		if err != nil {
+			writeSitesError(w, err)
+			return
+		}
+		writeGeoJSON(w, locationCollection(scoreLocations(locations, nearby, facility, profile)))
+		return
+	}
+
+	registry, err := siteRegistry(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+	candidates, err := registry.CandidateSites(r.URL.Query().Get("dataset"))
 	if err != nil {
-		http.Error(w, "Error reading datacenter locations: "+err.Error(), http.StatusInternalServerError)
+		writeSitesError(w, err)
 		return
 	}
 
 	// Create a simplified response with only lat/long
 	var response []map[string]float64
-	for _, loc := range locations {
+	for _, loc := range candidates {
 		response = append(response, map[string]float64{
 			"latitude":  loc.Latitude,
 			"longitude": loc.Longitude,
@@ -236,7 +283,7 @@ func PossibleDataCenterHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetPropertyDetailsHandler handles GET /api/property-details?lat=..&lng=..
+// GetPropertyDetailsHandler handles GET /api/property-details?lat=..&lng=..[&tier=..&cooling=..&profile=..&username=..]
 func GetPropertyDetailsHandler(w http.ResponseWriter, r *http.Request) {
 	addCORSHeaders(w)
 	if r.Method == http.MethodOptions {
@@ -267,28 +314,42 @@ func GetPropertyDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	const epsilon = 0.0001
-	locations, err := data.ReadDatacenterLocations("us_possible_locations.csv")
+	profile, err := scoringProfileFromQuery(r)
 	if err != nil {
-		http.Error(w, "Error reading datacenter locations: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	facility, ok := facilityFromQuery(r)
+	if !ok {
+		http.Error(w, "Unknown facility tier", http.StatusBadRequest)
 		return
 	}
+	if c := r.URL.Query().Get("cooling"); c != "" {
+		facility.Cooling = data.CoolingTechnology(c)
+		if _, err := facility.WithDefaults(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
-	existingDCs, err := data.ReadExistingDatacenters("us_datacenters.csv")
+	const epsilon = 0.0001
+	locations, nearby, err := loadSites(r)
 	if err != nil {
-		log.Printf("Warning: Could not read existing datacenter data: %v", err)
+		writeSitesError(w, err)
+		return
 	}
 
-	// Find the matching location
+	// Find the matching location, copied out of the shared rows
 	var matched *data.DatacenterLocation
 	for i := range locations {
 		if math.Abs(locations[i].Latitude-lat) < epsilon &&
 			math.Abs(locations[i].Longitude-lng) < epsilon {
-			matched = &locations[i]
+			loc := locations[i]
+			matched = &loc
 
 			// Calculate environmental metrics
-			allDCs := append(locations, existingDCs...)
-			CalculateResearchBasedMetrics(matched, allDCs) // see envcalcs.go
+			CalculateResearchBasedMetrics(matched, nearby, facility, profile) // see envcalcs.go
 			break
 		}
 	}
@@ -298,27 +359,157 @@ func GetPropertyDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return all details including environmental metrics
-	response := map[string]interface{}{
-		"location_name":            matched.Name,
-		"land_price":               matched.LandPrice,
-		"electricity":              matched.Electricity,
-		"notes":                    matched.Notes,
-		"eco_score":                matched.EcoScore,
-		"carbon_impact":            matched.CarbonImpact,
-		"temp_increase":            matched.TempIncrease,
-		"water_usage":              matched.WaterUsage,
-		"renewable_access":         matched.RenewableAccess,
-		"datacenter_density":       matched.DatacenterDensity,
-		"density_impact_score":     matched.DensityImpactScore,
-		"compounded_temp_increase": matched.CompoundedTempIncrease,
-		"water_competition":        matched.WaterCompetition,
-	}
+	response := propertyDetails(matched)
+	response["scoring_profile"] = profile
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// siteRegistry returns the registry of the country in the region query parameter, an ISO
+// 3166-1 alpha-2 code, or of the US datasets when it is absent.
+func siteRegistry(r *http.Request) (*data.Registry, error) {
+	return data.RegionRegistry(r.URL.Query().Get("region"))
+}
+
+// siteIndex holds the candidate locations of one region and dataset with the indexes
+// built over them. It is shared between requests and must not be modified.
+type siteIndex struct {
+	snapshot    *data.Snapshot
+	named       *data.Dataset // nil for the possible locations CSV
+	cartVersion uint64
+
+	// candidates is the dataset's rows; copy them before scoring in place.
+	candidates []data.DatacenterLocation
+	// candidateIndex holds only the candidates, for nearest-site lookups.
+	candidateIndex *data.SpatialIndex
+	// nearby also holds the existing datacenters and every cart item, for density.
+	nearby *data.SpatialIndex
+}
+
+type siteIndexKey struct {
+	registry *data.Registry
+	dataset  string
+}
+
+var (
+	siteIndexes   = make(map[siteIndexKey]*siteIndex)
+	siteIndexesMu sync.Mutex
+)
+
+// loadSiteIndex returns the shared siteIndex for the request's region and dataset query
+// parameters, rebuilding it when the datasets are reloaded or a cart changes. The region
+// parameter picks a country's datasets, and dataset an uploaded dataset instead of the
+// possible locations CSV.
+func loadSiteIndex(r *http.Request) (*siteIndex, error) {
+	registry, err := siteRegistry(r)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := registry.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	name := r.URL.Query().Get("dataset")
+	var named *data.Dataset
+	if name != "" {
+		if named, err = registry.Named(name); err != nil {
+			return nil, err
+		}
+	}
+	cartVersion := cart.Version()
+
+	key := siteIndexKey{registry, name}
+	siteIndexesMu.Lock()
+	defer siteIndexesMu.Unlock()
+	idx := siteIndexes[key]
+	if idx != nil && idx.snapshot == snapshot && idx.named == named && idx.cartVersion == cartVersion {
+		return idx, nil
+	}
+
+	candidates := snapshot.PossibleLocations.Rows
+	if named != nil {
+		candidates = named.Rows
+	}
+	candidateIndex := data.NewSpatialIndex(candidates)
+	if idx != nil && idx.snapshot == snapshot && idx.named == named {
+		// Only the carts changed.
+		candidateIndex = idx.candidateIndex
+	}
+	idx = &siteIndex{
+		snapshot:       snapshot,
+		named:          named,
+		cartVersion:    cartVersion,
+		candidates:     candidates,
+		candidateIndex: candidateIndex,
+		nearby:         data.NewSpatialIndex(candidates, snapshot.ExistingDatacenters.Rows, cart.AllItems()),
+	}
+	siteIndexes[key] = idx
+	return idx, nil
+}
+
+// loadSites returns the candidate locations, shared and not to be modified in place, and
+// the index of them together with the existing datacenters and every cart item for
+// neighbour queries. See loadSiteIndex for the query parameters.
+func loadSites(r *http.Request) ([]data.DatacenterLocation, *data.SpatialIndex, error) {
+	idx, err := loadSiteIndex(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return idx.candidates, idx.nearby, nil
+}
+
+// writeSitesError reports a loadSites failure, as 404 for an unknown dataset or region.
+func writeSitesError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, data.ErrUnknownDataset) || errors.Is(err, data.ErrUnknownRegion) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, "Error reading datacenter locations: "+err.Error(), status)
+}
+
+// facilityFromQuery returns the preset for the tier query parameter, or the Standard
+// facility when it is absent. ok is false for an unknown tier.
+func facilityFromQuery(r *http.Request) (facility data.FacilityProfile, ok bool) {
+	tier := r.URL.Query().Get("tier")
+	if tier == "" {
+		return data.StandardFacility(), true
+	}
+	return data.FacilityForTier(tier)
+}
+
+// propertyDetails returns all details of a scored location including environmental metrics
+func propertyDetails(loc *data.DatacenterLocation) map[string]interface{} {
+	return map[string]interface{}{
+		"location_name":            loc.Name,
+		"land_price":               loc.LandPrice,
+		"electricity":              loc.Electricity,
+		"notes":                    loc.Notes,
+		"tags":                     loc.Tags,
+		"hyperscalers":             loc.Hyperscalers,
+		"submarine_cable":          loc.SubmarineCable,
+		"government_presence":      loc.GovernmentPresence,
+		"country":                  loc.Country,
+		"subdivision":              loc.Subdivision,
+		"state":                    loc.State,
+		"state_fips":               loc.StateFIPS,
+		"county":                   loc.County,
+		"county_fips":              loc.CountyFIPS,
+		"eco_score":                loc.EcoScore,
+		"carbon_impact":            loc.CarbonImpact,
+		"temp_increase":            loc.TempIncrease,
+		"water_usage":              loc.WaterUsage,
+		"renewable_access":         loc.RenewableAccess,
+		"disaster_risk":            loc.DisasterRisk,
+		"datacenter_density":       loc.DatacenterDensity,
+		"density_impact_score":     loc.DensityImpactScore,
+		"compounded_temp_increase": loc.CompoundedTempIncrease,
+		"water_competition":        loc.WaterCompetition,
+		"facility":                 loc.Facility,
+		"cooling":                  loc.Cooling,
+	}
+}
+
 // addCORSHeaders is a helper that adds CORS-related headers
 func addCORSHeaders(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
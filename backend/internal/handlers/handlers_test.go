@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+)
+
+// TestMain runs the handler tests in a scratch directory holding copies of the site
+// CSVs, so the default registry finds them and carts and profiles written by the
+// handlers stay out of the source tree.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handlers-test")
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range []string{"us_possible_locations.csv", "us_datacenters.csv"} {
+		content, err := os.ReadFile(filepath.Join("..", "..", name))
+		if err != nil {
+			panic(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			panic(err)
+		}
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// serveJSON runs a request through handler and decodes the JSON response into v.
+func serveJSON(t *testing.T, handler http.HandlerFunc, req *http.Request, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code == http.StatusOK && v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("decoding %s response: %v", req.URL, err)
+		}
+	}
+	return rec
+}
+
+func TestLoadSiteIndexIsShared(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/sites/rank", nil)
+	first, err := loadSiteIndex(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := loadSiteIndex(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("index rebuilt without any change")
+	}
+
+	item := data.DatacenterLocation{Latitude: 39.0, Longitude: -77.4, Name: "Index test site"}
+	if err := cart.AddToCart("index-test", item, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer cart.DeleteCart("index-test")
+	third, err := loadSiteIndex(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Fatal("index not rebuilt after a cart change")
+	}
+	if third.nearby.Len() != first.nearby.Len()+1 {
+		t.Errorf("nearby holds %d sites, want %d", third.nearby.Len(), first.nearby.Len()+1)
+	}
+	if third.candidateIndex != first.candidateIndex {
+		t.Error("candidate index rebuilt after a cart change")
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/tiles"
+)
+
+// HeatmapCacheDir is where rendered heatmap tiles are kept; empty disables the cache.
+var HeatmapCacheDir = "./tile-cache"
+
+const (
+	// heatmapVersion is part of every cache key; bump it when the model or rendering changes.
+	heatmapVersion = 1
+
+	heatmapStep           = 8 // pixels between evaluated samples
+	defaultHeatmapOpacity = 0.7
+
+	// heatmapMaxKeys bounds the cached domains and the tile sets kept under HeatmapCacheDir.
+	heatmapMaxKeys = 64
+)
+
+// heatmapSettings is everything a heatmap tile depends on. Its hash is the cache key.
+type heatmapSettings struct {
+	Version  int                  `json:"version"`
+	Region   string               `json:"region"`
+	Dataset  string               `json:"dataset"` // Snapshot.Digest of the region's sites
+	Tables   string               `json:"tables"`  // data.TablesVersion of the region
+	Facility data.FacilityProfile `json:"facility"`
+	Profile  scoring.Profile      `json:"profile"`
+	Ramp     []string             `json:"ramp"`
+	Min      *float64             `json:"min"` // nil uses the range of the region's sites
+	Max      *float64             `json:"max"`
+	Opacity  float64              `json:"opacity"`
+}
+
+// key panics when the settings do not marshal. parseHeatmapRequest rejects the non-finite
+// numbers that would fail, so that is a bug, and hashing nothing would share one key.
+func (s heatmapSettings) key() string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		panic(fmt.Sprintf("marshalling heatmap settings: %v", err))
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// heatmapRequest is a parsed heatmap query with the datasets it draws on.
+type heatmapRequest struct {
+	settings heatmapSettings
+	ramp     tiles.Ramp
+	snapshot *data.Snapshot
+	nearby   *data.SpatialIndex // existing datacenters, built by prepare
+}
+
+// parseHeatmapRequest reads the region, tier, profile, username, ramp, min, max and
+// opacity parameters.
+func parseHeatmapRequest(r *http.Request) (*heatmapRequest, int, error) {
+	q := r.URL.Query()
+	registry, err := siteRegistry(r)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	snapshot, err := registry.Snapshot()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	facility, ok := facilityFromQuery(r)
+	if !ok {
+		return nil, http.StatusBadRequest, fmt.Errorf("unknown facility tier")
+	}
+	profile, err := scoringProfileFromQuery(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	ramp, err := tiles.ParseRamp(q.Get("ramp"))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	region := registry.Country()
+	if region == "" {
+		region = "US"
+	}
+	req := &heatmapRequest{
+		settings: heatmapSettings{
+			Version:  heatmapVersion,
+			Region:   region,
+			Dataset:  snapshot.Digest,
+			Tables:   data.TablesVersion(registry.Country()),
+			Facility: facility,
+			Profile:  profile,
+			Ramp:     ramp.Hex(),
+			Opacity:  defaultHeatmapOpacity,
+		},
+		ramp:     ramp,
+		snapshot: snapshot,
+	}
+	for name, dst := range map[string]**float64{"min": &req.settings.Min, "max": &req.settings.Max} {
+		if v := q.Get(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, http.StatusBadRequest, fmt.Errorf("invalid %s value", name)
+			}
+			*dst = &f
+		}
+	}
+	if v := q.Get("opacity"); v != "" {
+		if req.settings.Opacity, err = strconv.ParseFloat(v, 64); err != nil || !(req.settings.Opacity >= 0 && req.settings.Opacity <= 1) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid opacity value; use 0-1")
+		}
+	}
+	return req, http.StatusOK, nil
+}
+
+// prepare indexes the existing datacenters, which only scoring needs, so cached tiles are
+// served without it.
+func (h *heatmapRequest) prepare() {
+	if h.nearby == nil {
+		h.nearby = data.NewSpatialIndex(h.snapshot.ExistingDatacenters.Rows)
+	}
+}
+
+// rawEcoScore is calcEcoScore for a hypothetical facility at lat/lng, before clamping to
+// 1-100 so differences between poor sites still show. ok is false outside the country.
+// Call prepare first.
+func (h *heatmapRequest) rawEcoScore(lat, lng float64) (float64, bool) {
+	loc := data.DatacenterLocation{Latitude: lat, Longitude: lng}
+	env := data.GetEnvironmentalData(&loc, h.nearby)
+	if env.Place.CountryCode != h.settings.Region {
+		return 0, false
+	}
+	cooling := calculateCooling(h.settings.Facility, env.MonthlyClimate, env.DatacenterDensity)
+	impact := calculateImpact(env, h.settings.Facility, h.settings.Profile, cooling.AnnualPUE, cooling.AnnualWUE)
+	return calcEcoScore(impact.components, h.settings.Profile), true
+}
+
+var (
+	heatmapDomains   = make(map[string][2]float64)
+	heatmapDomainsMu sync.Mutex
+)
+
+// domain returns the scores mapped to the ends of the ramp: min and max when given, and
+// otherwise the lowest and highest score of the region's sites, so the colours spread over
+// the range that matters and stay the same from tile to tile.
+func (h *heatmapRequest) domain() (lo, hi float64) {
+	auto := h.settings
+	auto.Min, auto.Max, auto.Ramp, auto.Opacity = nil, nil, nil, 0
+	key := auto.key()
+
+	heatmapDomainsMu.Lock()
+	d, ok := heatmapDomains[key]
+	heatmapDomainsMu.Unlock()
+	if !ok {
+		h.prepare()
+		d = [2]float64{math.Inf(1), math.Inf(-1)}
+		for _, rows := range [][]data.DatacenterLocation{h.snapshot.PossibleLocations.Rows, h.snapshot.ExistingDatacenters.Rows} {
+			for _, site := range rows {
+				if v, ok := h.rawEcoScore(site.Latitude, site.Longitude); ok {
+					d[0], d[1] = math.Min(d[0], v), math.Max(d[1], v)
+				}
+			}
+		}
+		if d[0] > d[1] {
+			d = [2]float64{1, 100}
+		}
+		heatmapDomainsMu.Lock()
+		if len(heatmapDomains) >= heatmapMaxKeys {
+			for k := range heatmapDomains {
+				delete(heatmapDomains, k)
+				break
+			}
+		}
+		heatmapDomains[key] = d
+		heatmapDomainsMu.Unlock()
+	}
+
+	lo, hi = d[0], d[1]
+	if h.settings.Min != nil {
+		lo = *h.settings.Min
+	}
+	if h.settings.Max != nil {
+		hi = *h.settings.Max
+	}
+	return lo, hi
+}
+
+// HeatmapHandler handles GET /heatmap/{z}/{x}/{y}.png, a raster tile of the eco score a
+// hypothetical datacenter would get at each point, and GET /heatmap/legend, the scores and
+// colours at the ends of the ramp. Both take region, tier, profile, username, ramp (a name
+// from tiles.Ramps or comma-separated #rrggbb stops), min, max and opacity. Tiles are cached
+// under HeatmapCacheDir.
+func HeatmapHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/heatmap/")
+	var z, x, y int
+	if path != "legend" {
+		var err error
+		if z, x, y, err = parseTileCoords(path, ".png"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req, status, err := parseHeatmapRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	lo, hi := req.domain()
+	if path == "legend" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"min":     lo,
+			"max":     hi,
+			"ramp":    req.settings.Ramp,
+			"opacity": req.settings.Opacity,
+		})
+		return
+	}
+
+	cache := tiles.DiskCache{Dir: HeatmapCacheDir, Ext: "png", MaxKeys: heatmapMaxKeys}
+	key := req.settings.key()
+	content, ok := []byte(nil), false
+	if HeatmapCacheDir != "" {
+		content, ok = cache.Get(key, z, x, y)
+	}
+	if !ok {
+		req.prepare()
+		heatmap := tiles.Heatmap{Min: lo, Max: hi, Ramp: req.ramp, Opacity: req.settings.Opacity, Step: heatmapStep}
+		if content, err = heatmap.Render(z, x, y, req.rawEcoScore); err != nil {
+			http.Error(w, "Error rendering tile: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if HeatmapCacheDir != "" {
+			if err := cache.Put(key, z, x, y, content); err != nil {
+				log.Printf("Warning: could not cache heatmap tile: %v", err)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", tiles.PNGContentType)
+	w.Write(content)
+}
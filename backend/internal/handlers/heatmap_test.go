@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHeatmapLegend(t *testing.T) {
+	var legend struct {
+		Min, Max, Opacity float64
+		Ramp              []string
+	}
+	req := httptest.NewRequest(http.MethodGet, "/heatmap/legend", nil)
+	if rec := serveJSON(t, HeatmapHandler, req, &legend); rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if legend.Min >= legend.Max || legend.Opacity != defaultHeatmapOpacity || len(legend.Ramp) < 2 {
+		t.Errorf("legend = %+v", legend)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/heatmap/legend?min=10&max=90", nil)
+	serveJSON(t, HeatmapHandler, req, &legend)
+	if legend.Min != 10 || legend.Max != 90 {
+		t.Errorf("legend domain = %v-%v, want 10-90", legend.Min, legend.Max)
+	}
+}
+
+func TestHeatmapTileIsCached(t *testing.T) {
+	saved := HeatmapCacheDir
+	HeatmapCacheDir = t.TempDir()
+	defer func() { HeatmapCacheDir = saved }()
+
+	// Tile 5/9/12 covers northern Virginia.
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		HeatmapHandler(rec, httptest.NewRequest(http.MethodGet, "/heatmap/5/9/12.png", nil))
+		return rec
+	}
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 256 || b.Dy() != 256 {
+		t.Errorf("tile is %dx%d", b.Dx(), b.Dy())
+	}
+	cached, _ := filepath.Glob(filepath.Join(HeatmapCacheDir, "*", "5", "9", "12.png"))
+	if len(cached) != 1 {
+		t.Fatalf("cached tiles: %v", cached)
+	}
+
+	// A second request is served from the cache.
+	if err := os.WriteFile(cached[0], []byte("cached"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(); rec.Body.String() != "cached" {
+		t.Error("tile rendered again instead of served from the cache")
+	}
+}
+
+func TestHeatmapRejects(t *testing.T) {
+	for _, path := range []string{
+		"/heatmap/5/9.png",
+		"/heatmap/5/9/12.png?opacity=2",
+		"/heatmap/5/9/12.png?opacity=NaN",
+		"/heatmap/legend?opacity=NaN",
+		"/heatmap/legend?max=Inf",
+		"/heatmap/5/9/12.png?min=NaN",
+		"/heatmap/5/9/12.png?ramp=nope",
+		"/heatmap/5/9/12.png?tier=nope",
+	} {
+		rec := httptest.NewRecorder()
+		HeatmapHandler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, rec.Code)
+		}
+	}
+}
+
+func TestHeatmapSettingsKey(t *testing.T) {
+	a := heatmapSettings{Version: heatmapVersion, Region: "US", Opacity: 0.5}
+	b := a
+	b.Opacity = 0.6
+	if a.key() == b.key() {
+		t.Error("different settings share a cache key")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("settings that do not marshal were hashed")
+		}
+	}()
+	a.Opacity = math.NaN()
+	a.key()
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/optimizer"
+)
+
+const acresPerHectare = 2.471
+
+// siteCost is the facility build cost plus its land footprint at the midpoint land price.
+func siteCost(loc *data.DatacenterLocation, facility data.FacilityProfile) (float64, error) {
+	land, err := loc.LandPricePerAcre()
+	if err != nil {
+		return 0, err
+	}
+	return facility.BuildCost + land.Mid()*facility.LandFootprintHectares*acresPerHectare, nil
+}
+
+// OptimizeHandler handles GET /api/optimize?budget=..|username=..[&tier=..&max_sites=..&seed=..
+// &population=..&generations=..&profile=..]. Without a budget the user's Cart.MoneyLeft is used,
+// and sites already in that cart are left out, as are sites whose land price cannot be
+// parsed; those are listed in price_errors.
+func OptimizeHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	profile, err := scoringProfileFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	facility, ok := facilityFromQuery(r)
+	if !ok {
+		http.Error(w, "Unknown facility tier", http.StatusBadRequest)
+		return
+	}
+
+	opts := optimizer.Options{Seed: time.Now().UnixNano()}
+	if v := q.Get("seed"); v != "" {
+		if opts.Seed, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, "Invalid seed value", http.StatusBadRequest)
+			return
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"max_sites", &opts.MaxSites}, {"population", &opts.Population}, {"generations", &opts.Generations}} {
+		if v := q.Get(p.name); v != "" {
+			if *p.dst, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Invalid "+p.name+" value", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	username := q.Get("username")
+	userCart, hasCart := cart.GetCart(username)
+	budgetSource := "query"
+	if v := q.Get("budget"); v != "" {
+		if opts.Budget, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "Invalid budget value", http.StatusBadRequest)
+			return
+		}
+	} else if hasCart {
+		opts.Budget = userCart.MoneyLeft
+		budgetSource = "cart"
+	} else {
+		http.Error(w, "Missing budget or username with a cart", http.StatusBadRequest)
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locations, nearby, err := loadSites(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+	if hasCart {
+		const epsilon = 0.0001
+		var remaining []data.DatacenterLocation
+		for _, loc := range locations {
+			owned := false
+			for _, item := range userCart.Items {
+				if math.Abs(loc.Latitude-item.Latitude) < epsilon && math.Abs(loc.Longitude-item.Longitude) < epsilon {
+					owned = true
+					break
+				}
+			}
+			if !owned {
+				remaining = append(remaining, loc)
+			}
+		}
+		locations = remaining
+	}
+
+	scored := scoreLocations(locations, nearby, facility, profile)
+	var (
+		priced      []data.DatacenterLocation
+		candidates  []optimizer.Candidate
+		priceErrors []data.PriceParseError
+	)
+	for i := range scored {
+		loc := &scored[i]
+		cost, err := siteCost(loc, facility)
+		if err != nil {
+			priceErrors = append(priceErrors, data.PriceParseError{Row: i + 1, Name: loc.Name, Field: "land_price", Value: loc.LandPrice, Err: err.Error()})
+			continue
+		}
+		priced = append(priced, *loc)
+		candidates = append(candidates, optimizer.Candidate{
+			Cost:     cost,
+			Carbon:   loc.CarbonImpact,
+			Water:    loc.WaterUsage,
+			Capacity: facility.ITLoadMW,
+		})
+	}
+
+	front, err := optimizer.ParetoFront(r.Context(), candidates, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	portfolios := make([]map[string]interface{}, 0, len(front))
+	for _, p := range front {
+		sites := make([]map[string]interface{}, 0, len(p.Sites))
+		for _, i := range p.Sites {
+			loc := &priced[i]
+			sites = append(sites, map[string]interface{}{
+				"location_name": loc.Name,
+				"latitude":      loc.Latitude,
+				"longitude":     loc.Longitude,
+				"state":         loc.State,
+				"cost":          candidates[i].Cost,
+				"eco_score":     loc.EcoScore,
+				"carbon_impact": loc.CarbonImpact,
+				"water_usage":   loc.WaterUsage,
+			})
+		}
+		portfolios = append(portfolios, map[string]interface{}{
+			"sites":         sites,
+			"site_count":    len(p.Sites),
+			"total_cost":    p.Cost,
+			"carbon_impact": p.Carbon,
+			"water_usage":   p.Water,
+			"capacity_mw":   p.Capacity,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"budget":          opts.Budget,
+		"budget_source":   budgetSource,
+		"seed":            opts.Seed,
+		"facility":        facility,
+		"scoring_profile": profile,
+		"portfolios":      portfolios,
+		"price_errors":    priceErrors,
+	})
+}
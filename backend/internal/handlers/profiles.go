@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+// SaveScoringProfileRequest is the expected JSON payload for saving a profile.
+// An empty username saves a global profile, which needs the admin token.
+type SaveScoringProfileRequest struct {
+	Username string          `json:"username"`
+	Profile  scoring.Profile `json:"profile"`
+}
+
+// scoringProfileFromQuery resolves the profile and username query parameters,
+// defaulting to the built-in profile.
+func scoringProfileFromQuery(r *http.Request) (scoring.Profile, error) {
+	name := r.URL.Query().Get("profile")
+	p, ok := scoring.GetProfile(r.URL.Query().Get("username"), name)
+	if !ok {
+		return p, fmt.Errorf("unknown scoring profile %q", name)
+	}
+	return p, nil
+}
+
+// ScoringProfilesHandler handles GET /api/scoring-profiles?username=.., POST /api/scoring-profiles
+// with a SaveScoringProfileRequest body, and DELETE /api/scoring-profiles?name=..[&username=..].
+// Saving or deleting a global profile (no username) requires the admin token.
+func ScoringProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scoring.ListProfiles(r.URL.Query().Get("username")))
+	case http.MethodPost:
+		var req SaveScoringProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		if req.Username == "" && !authorizeAdmin(r) {
+			http.Error(w, "Global profiles need the admin token", http.StatusForbidden)
+			return
+		}
+		req.Profile.Owner = req.Username
+		saved, err := scoring.SaveProfile(req.Profile)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error saving scoring profile: %v", err), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(saved)
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name parameter is required", http.StatusBadRequest)
+			return
+		}
+		username := r.URL.Query().Get("username")
+		if username == "" && !authorizeAdmin(r) {
+			http.Error(w, "Global profiles need the admin token", http.StatusForbidden)
+			return
+		}
+		if err := scoring.DeleteProfile(username, name); err != nil {
+			http.Error(w, fmt.Sprintf("Error deleting scoring profile: %v", err), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "success",
+			"message": "Scoring profile deleted",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
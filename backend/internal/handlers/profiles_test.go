@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+func profileRequest(method, target, body, token string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestScoringProfilesHandler(t *testing.T) {
+	saved := AdminToken
+	AdminToken = "test-admin-token"
+	defer func() { AdminToken = saved }()
+
+	const global = `{"profile":{"name":"handler-global","weights":{"carbon":0.5,"water":0.5}}}`
+	for _, token := range []string{"", "wrong"} {
+		rec := serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodPost, "/api/scoring-profiles", global, token), nil)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("global save with token %q: status %d, want 403", token, rec.Code)
+		}
+	}
+	if _, ok := scoring.GetProfile("", "handler-global"); ok {
+		t.Fatal("unauthorised global save stored the profile")
+	}
+	if rec := serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodPost, "/api/scoring-profiles", global, AdminToken), nil); rec.Code != http.StatusOK {
+		t.Fatalf("admin global save: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodDelete, "/api/scoring-profiles?name=handler-global", "", ""), nil); rec.Code != http.StatusForbidden {
+		t.Errorf("global delete without a token: status %d, want 403", rec.Code)
+	}
+
+	const mine = `{"username":"profile-tester","profile":{"name":"handler-mine","weights":{"carbon":0.2,"water":0.2,"temp":0.2,"land":0.2,"social":0.2}}}`
+	var got scoring.Profile
+	if rec := serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodPost, "/api/scoring-profiles", mine, ""), &got); rec.Code != http.StatusOK || got.Owner != "profile-tester" {
+		t.Fatalf("user save: status %d, owner %q: %s", rec.Code, got.Owner, rec.Body)
+	}
+	var list []scoring.Profile
+	serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodGet, "/api/scoring-profiles?username=profile-tester", "", ""), &list)
+	names := map[string]bool{}
+	for _, p := range list {
+		names[p.Name] = true
+	}
+	if !names[scoring.DefaultProfileName] || !names["handler-global"] || !names["handler-mine"] {
+		t.Errorf("listed %v", names)
+	}
+
+	for _, target := range []string{"/api/scoring-profiles?name=handler-mine&username=profile-tester", "/api/scoring-profiles?name=handler-global"} {
+		if rec := serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodDelete, target, "", AdminToken), nil); rec.Code != http.StatusOK {
+			t.Errorf("DELETE %s: status %d: %s", target, rec.Code, rec.Body)
+		}
+	}
+	if _, ok := scoring.GetProfile("profile-tester", "handler-mine"); ok {
+		t.Error("deleted profile still found")
+	}
+}
+
+func TestScoringProfilesHandlerRejectsInvalidWeights(t *testing.T) {
+	for _, weights := range []string{
+		`{"carbon":0.5}`,
+		`{"carbon":1.5,"water":-0.5}`,
+		`{"carbon":"high"}`,
+	} {
+		body := `{"username":"profile-tester","profile":{"name":"invalid","weights":` + weights + `}}`
+		rec := serveJSON(t, ScoringProfilesHandler, profileRequest(http.MethodPost, "/api/scoring-profiles", body, ""), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("weights %s: status %d, want 400", weights, rec.Code)
+		}
+	}
+	if _, ok := scoring.GetProfile("profile-tester", "invalid"); ok {
+		t.Error("invalid profile stored")
+	}
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+const (
+	defaultRankPageSize = 50
+	maxRankPageSize     = 500
+)
+
+// RankFilter limits which sites are ranked. Zero fields do not filter.
+type RankFilter struct {
+	Country            string   `json:"country,omitempty"` // ISO 3166-1 alpha-2; sites without one count as US
+	State              string   `json:"state,omitempty"`
+	MaxLandPrice       float64  `json:"max_land_price,omitempty"`  // $/acre, compared with the midpoint of the listed range
+	MaxElectricity     float64  `json:"max_electricity,omitempty"` // $/kWh, compared with the midpoint of the listed range
+	MinRenewableAccess int      `json:"min_renewable_access,omitempty"`
+	MaxDisasterRisk    *float64 `json:"max_disaster_risk,omitempty"` // 0-1
+	// Hyperscaler keeps sites noting that operator; "any" keeps sites noting any of them.
+	Hyperscaler        string `json:"hyperscaler,omitempty"`
+	SubmarineCable     *bool  `json:"submarine_cable,omitempty"`
+	GovernmentPresence *bool  `json:"government_presence,omitempty"`
+}
+
+// RankOptions controls filtering, ordering and paging of RankSites.
+type RankOptions struct {
+	Filter   RankFilter
+	Sort     string // one of rankSortKeys, default eco_score
+	Order    string // "asc" or "desc", default depends on the sort key
+	Page     int    // 1-based
+	PageSize int
+}
+
+// RankedSite is a scored candidate location and its position in the ranking.
+type RankedSite struct {
+	Rank             int
+	Location         data.DatacenterLocation
+	LandPricePerAcre float64
+	ElectricityRate  float64
+}
+
+// RankResult is one page of ranked sites.
+type RankResult struct {
+	Total    int
+	Page     int
+	PageSize int
+	Sort     string
+	Order    string
+	Sites    []RankedSite
+	// PriceErrors lists candidate locations whose prices could not be parsed; they never
+	// pass a price filter.
+	PriceErrors []data.PriceParseError
+}
+
+// rankSortKey reads the sort value of a site; descending marks keys where higher is better.
+type rankSortKey struct {
+	value      func(s *RankedSite) float64
+	descending bool
+}
+
+var rankSortKeys = map[string]rankSortKey{
+	"eco_score":          {func(s *RankedSite) float64 { return float64(s.Location.EcoScore) }, true},
+	"carbon_impact":      {func(s *RankedSite) float64 { return s.Location.CarbonImpact }, false},
+	"water_usage":        {func(s *RankedSite) float64 { return s.Location.WaterUsage }, false},
+	"temp_increase":      {func(s *RankedSite) float64 { return s.Location.TempIncrease }, false},
+	"land_price":         {func(s *RankedSite) float64 { return s.LandPricePerAcre }, false},
+	"electricity":        {func(s *RankedSite) float64 { return s.ElectricityRate }, false},
+	"renewable_access":   {func(s *RankedSite) float64 { return float64(s.Location.RenewableAccess) }, true},
+	"disaster_risk":      {func(s *RankedSite) float64 { return s.Location.DisasterRisk }, false},
+	"datacenter_density": {func(s *RankedSite) float64 { return float64(s.Location.DatacenterDensity) }, false},
+}
+
+// RankSites scores every location in parallel, filters and sorts them, and returns the
+// requested page. Ranks are positions in the filtered, sorted list; ties keep file order.
+func RankSites(locations []data.DatacenterLocation, nearby *data.SpatialIndex, facility data.FacilityProfile, profile scoring.Profile, opts RankOptions) (RankResult, error) {
+	if opts.Sort == "" {
+		opts.Sort = "eco_score"
+	}
+	key, ok := rankSortKeys[opts.Sort]
+	if !ok {
+		return RankResult{}, fmt.Errorf("unknown sort key %q", opts.Sort)
+	}
+	descending := key.descending
+	switch opts.Order {
+	case "":
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	default:
+		return RankResult{}, fmt.Errorf("order must be asc or desc")
+	}
+	if opts.Page == 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize == 0 {
+		opts.PageSize = defaultRankPageSize
+	}
+	if opts.Page < 1 || opts.PageSize < 1 || opts.PageSize > maxRankPageSize {
+		return RankResult{}, fmt.Errorf("page must be at least 1 and page_size between 1 and %d", maxRankPageSize)
+	}
+
+	scored := scoreLocations(locations, nearby, facility, profile)
+	sites := make([]RankedSite, len(scored))
+	for i := range scored {
+		s := &sites[i]
+		s.Location = scored[i]
+		if land, err := s.Location.LandPricePerAcre(); err == nil {
+			s.LandPricePerAcre = land.Mid()
+		}
+		if rate, err := s.Location.ElectricityPerKWh(); err == nil {
+			s.ElectricityRate = rate.Mid()
+		}
+	}
+
+	filtered := sites[:0]
+	for _, s := range sites {
+		if opts.Filter.matches(&s) {
+			filtered = append(filtered, s)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := key.value(&filtered[i]), key.value(&filtered[j])
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+	for i := range filtered {
+		filtered[i].Rank = i + 1
+	}
+
+	result := RankResult{
+		Total:    len(filtered),
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+		Sort:     opts.Sort,
+		Order:    "asc",
+	}
+	if descending {
+		result.Order = "desc"
+	}
+	// Pages past the last are empty; compare page counts so a huge page cannot overflow.
+	if pages := (len(filtered) + opts.PageSize - 1) / opts.PageSize; opts.Page <= pages {
+		start := (opts.Page - 1) * opts.PageSize
+		end := min(start+opts.PageSize, len(filtered))
+		result.Sites = filtered[start:end]
+	}
+	result.PriceErrors = data.CheckPrices(locations)
+	return result, nil
+}
+
+// scoreLocations runs CalculateResearchBasedMetrics on a copy of every location across all CPUs.
+func scoreLocations(locations []data.DatacenterLocation, nearby *data.SpatialIndex, facility data.FacilityProfile, profile scoring.Profile) []data.DatacenterLocation {
+	scored := make([]data.DatacenterLocation, len(locations))
+	copy(scored, locations)
+	workers := runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(scored); i += workers {
+				CalculateResearchBasedMetrics(&scored[i], nearby, facility, profile)
+			}
+		}(w)
+	}
+	wg.Wait()
+	return scored
+}
+
+func (f RankFilter) matches(s *RankedSite) bool {
+	switch {
+	case f.Country != "" && !strings.EqualFold(f.Country, siteCountry(&s.Location)):
+		return false
+	case f.State != "" && !strings.EqualFold(f.State, s.Location.State):
+		return false
+	case f.MaxLandPrice > 0 && (s.LandPricePerAcre == 0 || s.LandPricePerAcre > f.MaxLandPrice):
+		return false
+	case f.MaxElectricity > 0 && (s.ElectricityRate == 0 || s.ElectricityRate > f.MaxElectricity):
+		return false
+	case s.Location.RenewableAccess < f.MinRenewableAccess:
+		return false
+	case f.MaxDisasterRisk != nil && s.Location.DisasterRisk > *f.MaxDisasterRisk:
+		return false
+	case f.SubmarineCable != nil && s.Location.SubmarineCable != *f.SubmarineCable:
+		return false
+	case f.GovernmentPresence != nil && s.Location.GovernmentPresence != *f.GovernmentPresence:
+		return false
+	case f.Hyperscaler == "":
+		return true
+	case strings.EqualFold(f.Hyperscaler, "any"):
+		return len(s.Location.Hyperscalers) > 0
+	}
+	for _, h := range s.Location.Hyperscalers {
+		if strings.EqualFold(h, f.Hyperscaler) {
+			return true
+		}
+	}
+	return false
+}
+
+// siteCountry is the location's country code, taking sites without one to be in the US.
+func siteCountry(loc *data.DatacenterLocation) string {
+	if loc.Country == "" {
+		return "US"
+	}
+	return loc.Country
+}
+
+// RankSitesHandler handles GET /api/sites/rank over every candidate location, with optional
+// country, state, max_land_price, max_electricity, min_renewable_access, max_disaster_risk, hyperscaler,
+// submarine_cable and government_presence filters, sort and order, page and page_size, and
+// tier, profile and username.
+func RankSitesHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	profile, err := scoringProfileFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	facility, ok := facilityFromQuery(r)
+	if !ok {
+		http.Error(w, "Unknown facility tier", http.StatusBadRequest)
+		return
+	}
+
+	opts := RankOptions{
+		Filter: RankFilter{Country: q.Get("country"), State: q.Get("state"), Hyperscaler: q.Get("hyperscaler")},
+		Sort:   q.Get("sort"),
+		Order:  q.Get("order"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  *float64
+	}{{"max_land_price", &opts.Filter.MaxLandPrice}, {"max_electricity", &opts.Filter.MaxElectricity}} {
+		if v := q.Get(p.name); v != "" {
+			if *p.dst, err = strconv.ParseFloat(v, 64); err != nil {
+				http.Error(w, "Invalid "+p.name+" value", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if v := q.Get("max_disaster_risk"); v != "" {
+		risk, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, "Invalid max_disaster_risk value", http.StatusBadRequest)
+			return
+		}
+		opts.Filter.MaxDisasterRisk = &risk
+	}
+	for _, p := range []struct {
+		name string
+		dst  **bool
+	}{{"submarine_cable", &opts.Filter.SubmarineCable}, {"government_presence", &opts.Filter.GovernmentPresence}} {
+		if v := q.Get(p.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "Invalid "+p.name+" value", http.StatusBadRequest)
+				return
+			}
+			*p.dst = &b
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"min_renewable_access", &opts.Filter.MinRenewableAccess}, {"page", &opts.Page}, {"page_size", &opts.PageSize}} {
+		if v := q.Get(p.name); v != "" {
+			if *p.dst, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Invalid "+p.name+" value", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	locations, nearby, err := loadSites(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+
+	result, err := RankSites(locations, nearby, facility, profile, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sites := make([]map[string]interface{}, 0, len(result.Sites))
+	for i := range result.Sites {
+		s := &result.Sites[i]
+		site := propertyDetails(&s.Location)
+		site["rank"] = s.Rank
+		site["latitude"] = s.Location.Latitude
+		site["longitude"] = s.Location.Longitude
+		site["land_price_per_acre"] = s.LandPricePerAcre
+		site["electricity_rate"] = s.ElectricityRate
+		sites = append(sites, site)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":           result.Total,
+		"page":            result.Page,
+		"page_size":       result.PageSize,
+		"sort":            result.Sort,
+		"order":           result.Order,
+		"scoring_profile": profile,
+		"facility":        facility,
+		"sites":           sites,
+		"price_errors":    result.PriceErrors,
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+// rankTestSites are candidate locations in three states, in file order.
+var rankTestSites = []data.DatacenterLocation{
+	{Latitude: 39.0438, Longitude: -77.4874, Name: "Ashburn, VA", LandPrice: "$1,500,000-2,500,000/acre", Electricity: "$0.06-0.08/kWh",
+		Hyperscalers: []string{"AWS"}, GovernmentPresence: true},
+	{Latitude: 34.7304, Longitude: -86.5861, Name: "Huntsville, AL", LandPrice: "$75,000-150,000/acre", Electricity: "$0.0972/kWh"},
+	{Latitude: 45.5946, Longitude: -121.1787, Name: "The Dalles, OR", LandPrice: "$50,000-100,000/acre", Electricity: "$0.05/kWh",
+		Hyperscalers: []string{"Google"}},
+	{Latitude: 37.5407, Longitude: -77.4360, Name: "Richmond, VA", LandPrice: "call for price", Electricity: "$0.07/kWh", SubmarineCable: true},
+}
+
+func rank(t *testing.T, opts RankOptions) RankResult {
+	t.Helper()
+	result, err := RankSites(rankTestSites, nil, data.StandardFacility(), scoring.DefaultProfile(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func rankedNames(result RankResult) []string {
+	names := make([]string, len(result.Sites))
+	for i, s := range result.Sites {
+		names[i] = s.Location.Name
+	}
+	return names
+}
+
+func TestRankSitesFilters(t *testing.T) {
+	yes := true
+	tests := []struct {
+		name   string
+		filter RankFilter
+		want   []string
+	}{
+		{"state", RankFilter{State: "va"}, []string{"Ashburn, VA", "Richmond, VA"}},
+		{"country", RankFilter{Country: "CA"}, nil},
+		{"land price", RankFilter{MaxLandPrice: 200000}, []string{"Huntsville, AL", "The Dalles, OR"}},
+		{"electricity", RankFilter{MaxElectricity: 0.06}, []string{"The Dalles, OR"}},
+		{"hyperscaler", RankFilter{Hyperscaler: "google"}, []string{"The Dalles, OR"}},
+		{"any hyperscaler", RankFilter{Hyperscaler: "any"}, []string{"Ashburn, VA", "The Dalles, OR"}},
+		{"submarine cable", RankFilter{SubmarineCable: &yes}, []string{"Richmond, VA"}},
+		{"government presence", RankFilter{GovernmentPresence: &yes}, []string{"Ashburn, VA"}},
+	}
+	for _, tt := range tests {
+		result := rank(t, RankOptions{Filter: tt.filter})
+		got := map[string]bool{}
+		for _, name := range rankedNames(result) {
+			got[name] = true
+		}
+		if result.Total != len(tt.want) || len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, rankedNames(result), tt.want)
+			continue
+		}
+		for _, name := range tt.want {
+			if !got[name] {
+				t.Errorf("%s: got %v, want %v", tt.name, rankedNames(result), tt.want)
+			}
+		}
+	}
+
+	if result := rank(t, RankOptions{}); len(result.PriceErrors) != 1 || result.PriceErrors[0].Name != "Richmond, VA" {
+		t.Errorf("price errors = %+v", result.PriceErrors)
+	}
+}
+
+func TestRankSitesSortOrder(t *testing.T) {
+	result := rank(t, RankOptions{Sort: "land_price"})
+	if result.Order != "asc" {
+		t.Errorf("land_price sorts %s by default, want asc", result.Order)
+	}
+	// Richmond's price does not parse, so it sorts as 0.
+	want := []string{"Richmond, VA", "The Dalles, OR", "Huntsville, AL", "Ashburn, VA"}
+	if got := rankedNames(result); len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] || result.Sites[i].Rank != i+1 {
+				t.Errorf("rank %d: got %s (rank %d), want %s", i+1, got[i], result.Sites[i].Rank, want[i])
+			}
+		}
+	}
+
+	result = rank(t, RankOptions{})
+	if result.Sort != "eco_score" || result.Order != "desc" {
+		t.Errorf("default ordering is %s %s, want eco_score desc", result.Sort, result.Order)
+	}
+	for i := 1; i < len(result.Sites); i++ {
+		if result.Sites[i].Location.EcoScore > result.Sites[i-1].Location.EcoScore {
+			t.Errorf("eco scores not descending: %v", rankedNames(result))
+		}
+	}
+	asc := rank(t, RankOptions{Order: "asc"})
+	for i := 1; i < len(asc.Sites); i++ {
+		if asc.Sites[i].Location.EcoScore < asc.Sites[i-1].Location.EcoScore {
+			t.Errorf("eco scores not ascending: %v", rankedNames(asc))
+		}
+	}
+
+	for _, opts := range []RankOptions{{Sort: "name"}, {Order: "up"}} {
+		if _, err := RankSites(rankTestSites, nil, data.StandardFacility(), scoring.DefaultProfile(), opts); err == nil {
+			t.Errorf("%+v accepted", opts)
+		}
+	}
+}
+
+func TestRankSitesPagination(t *testing.T) {
+	all := rankedNames(rank(t, RankOptions{}))
+	tests := []struct {
+		page, pageSize int
+		want           []string
+	}{
+		{1, 3, all[:3]},
+		{2, 3, all[3:]},
+		{3, 3, nil},
+		{1, 4, all},
+		{2, 4, nil},
+		{1 << 62, 2, nil},
+		{1<<63 - 1, maxRankPageSize, nil},
+	}
+	for _, tt := range tests {
+		result := rank(t, RankOptions{Page: tt.page, PageSize: tt.pageSize})
+		got := rankedNames(result)
+		if result.Total != len(all) || len(got) != len(tt.want) {
+			t.Errorf("page %d of %d: got %v (total %d), want %v", tt.page, tt.pageSize, got, result.Total, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("page %d of %d: got %v, want %v", tt.page, tt.pageSize, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for _, opts := range []RankOptions{{Page: -1}, {PageSize: -1}, {PageSize: maxRankPageSize + 1}} {
+		if _, err := RankSites(rankTestSites, nil, data.StandardFacility(), scoring.DefaultProfile(), opts); err == nil {
+			t.Errorf("%+v accepted", opts)
+		}
+	}
+}
+
+func TestRankSitesHandlerPastLastPage(t *testing.T) {
+	var got struct {
+		Total int
+		Sites []interface{}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/sites/rank?page=4611686018427387905&page_size=2", nil)
+	if rec := serveJSON(t, RankSitesHandler, req, &got); rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got.Total == 0 || len(got.Sites) != 0 {
+		t.Errorf("got %d sites of %d, want an empty page", len(got.Sites), got.Total)
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+// ScoreRequest describes an arbitrary site to score. Empty fields fall back to the
+// nearest known candidate location; the facility falls back to the Standard tier.
+// Tier and ITLoadMW override the matching fields of Facility.
+type ScoreRequest struct {
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Name        string  `json:"name,omitempty"`
+	LandPrice   string  `json:"land_price,omitempty"`
+	Electricity string  `json:"electricity,omitempty"`
+	ITLoadMW    float64 `json:"it_load_mw,omitempty"`
+	Tier        string  `json:"tier,omitempty"`
+
+	// Profile names the scoring profile, looked up among Username's own profiles first.
+	Profile  string `json:"profile,omitempty"`
+	Username string `json:"username,omitempty"`
+
+	Facility *data.FacilityProfile `json:"facility,omitempty"`
+
+	// Uncertainty, when set, also runs a Monte Carlo estimate of the metrics.
+	Uncertainty *UncertaintyOptions `json:"uncertainty,omitempty"`
+}
+
+// ScoreResult is the scored site plus where any missing inputs came from.
+type ScoreResult struct {
+	Location    data.DatacenterLocation `json:"location"`
+	NearestSite *data.Neighbour         `json:"nearest_site,omitempty"`
+	Fallbacks   []string                `json:"fallbacks,omitempty"`
+	Profile     scoring.Profile         `json:"scoring_profile"`
+	Uncertainty *UncertaintyResult      `json:"uncertainty,omitempty"`
+}
+
+// ScoreCoordinate runs the research-based model for any coordinate. The nearest site in
+// candidates supplies land price and electricity defaults; nearby supplies neighbouring
+// sites for density.
+func ScoreCoordinate(req ScoreRequest, candidates, nearby *data.SpatialIndex) (ScoreResult, error) {
+	if !data.ValidCoordinate(req.Latitude, req.Longitude) {
+		return ScoreResult{}, fmt.Errorf("coordinates out of range: %f, %f", req.Latitude, req.Longitude)
+	}
+	var facility data.FacilityProfile
+	if req.Facility != nil {
+		facility = *req.Facility
+	}
+	if req.Tier != "" {
+		facility.Tier = req.Tier
+	}
+	if req.ITLoadMW != 0 {
+		facility.ITLoadMW = req.ITLoadMW
+	}
+	usedDefaultFacility := facility == data.FacilityProfile{}
+	facility, err := facility.WithDefaults()
+	if err != nil {
+		return ScoreResult{}, err
+	}
+	profile, ok := scoring.GetProfile(req.Username, req.Profile)
+	if !ok {
+		return ScoreResult{}, fmt.Errorf("unknown scoring profile %q", req.Profile)
+	}
+
+	loc := data.DatacenterLocation{
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Name:        req.Name,
+		LandPrice:   req.LandPrice,
+		Electricity: req.Electricity,
+	}
+	result := ScoreResult{Profile: profile}
+
+	if nearest := candidates.Nearest(req.Latitude, req.Longitude, 1); len(nearest) > 0 {
+		n := nearest[0]
+		result.NearestSite = &n
+		if loc.Name == "" {
+			loc.Name = fmt.Sprintf("Custom site near %s", n.Location.Name)
+		}
+		if loc.LandPrice == "" {
+			loc.LandPrice = n.Location.LandPrice
+			result.Fallbacks = append(result.Fallbacks, "land_price")
+		}
+		if loc.Electricity == "" {
+			loc.Electricity = n.Location.Electricity
+			result.Fallbacks = append(result.Fallbacks, "electricity")
+		}
+	}
+
+	if usedDefaultFacility {
+		result.Fallbacks = append(result.Fallbacks, "facility")
+	}
+
+	if req.Uncertainty != nil {
+		u, err := EstimateUncertainty(&loc, nearby, facility, profile, *req.Uncertainty)
+		if err != nil {
+			return ScoreResult{}, err
+		}
+		result.Uncertainty = &u
+	}
+
+	CalculateResearchBasedMetrics(&loc, nearby, facility, profile)
+	result.Location = loc
+	return result, nil
+}
+
+// ScoreCoordinateHandler handles GET /api/score?lat=..&lng=..[&land_price=..&electricity=..&tier=..&cooling=..&it_load_mw=..&samples=..&seed=..&profile=..&username=..]
+// and POST /api/score with a ScoreRequest body.
+func ScoreCoordinateHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req ScoreRequest
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+	case http.MethodGet:
+		q := r.URL.Query()
+		if q.Get("lat") == "" || q.Get("lng") == "" {
+			http.Error(w, "Missing latitude or longitude parameters", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if req.Latitude, err = strconv.ParseFloat(q.Get("lat"), 64); err != nil {
+			http.Error(w, "Invalid latitude format", http.StatusBadRequest)
+			return
+		}
+		if req.Longitude, err = strconv.ParseFloat(q.Get("lng"), 64); err != nil {
+			http.Error(w, "Invalid longitude format", http.StatusBadRequest)
+			return
+		}
+		if v := q.Get("it_load_mw"); v != "" {
+			if req.ITLoadMW, err = strconv.ParseFloat(v, 64); err != nil || math.IsNaN(req.ITLoadMW) || math.IsInf(req.ITLoadMW, 0) {
+				http.Error(w, "Invalid it_load_mw format", http.StatusBadRequest)
+				return
+			}
+		}
+		if q.Get("samples") != "" || q.Get("seed") != "" {
+			req.Uncertainty = &UncertaintyOptions{}
+			if v := q.Get("samples"); v != "" {
+				if req.Uncertainty.Samples, err = strconv.Atoi(v); err != nil {
+					http.Error(w, "Invalid samples value", http.StatusBadRequest)
+					return
+				}
+			}
+			if v := q.Get("seed"); v != "" {
+				seed, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid seed value", http.StatusBadRequest)
+					return
+				}
+				req.Uncertainty.Seed = &seed
+			}
+		}
+		req.Tier = q.Get("tier")
+		req.Profile = q.Get("profile")
+		req.Username = q.Get("username")
+		if c := q.Get("cooling"); c != "" {
+			req.Facility = &data.FacilityProfile{Cooling: data.CoolingTechnology(c)}
+		}
+		req.Name = q.Get("name")
+		req.LandPrice = q.Get("land_price")
+		req.Electricity = q.Get("electricity")
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sites, err := loadSiteIndex(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+
+	result, err := ScoreCoordinate(req, sites.candidateIndex, sites.nearby)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := propertyDetails(&result.Location)
+	response["latitude"] = result.Location.Latitude
+	response["longitude"] = result.Location.Longitude
+	response["nearest_site"] = result.NearestSite
+	response["fallbacks"] = result.Fallbacks
+	response["scoring_profile"] = result.Profile
+	if result.Uncertainty != nil {
+		response["uncertainty"] = result.Uncertainty
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScoreCoordinateRejectsInvalidCoordinates(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	tests := []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"NaN", nan, nan},
+		{"NaN longitude", 39, nan},
+		{"infinite latitude", inf, -77},
+		{"out of range", 95, -77},
+	}
+	for _, tt := range tests {
+		_, err := ScoreCoordinate(ScoreRequest{Latitude: tt.lat, Longitude: tt.lng}, nil, nil)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestScoreCoordinateHandlerResolvesState(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantState string
+		wantFIPS  string
+	}{
+		{"Ashburn", "lat=39.0438&lng=-77.4874", "VA", "51"},
+		{"Manhattan", "lat=40.7411&lng=-74.0032", "NY", "36"},
+		{"Secaucus", "lat=40.7771&lng=-74.0712", "NJ", "34"},
+		{"Pittsburg, KS", "lat=37.4109&lng=-94.7050", "KS", "20"},
+	}
+	for _, tt := range tests {
+		var got map[string]interface{}
+		rec := serveJSON(t, ScoreCoordinateHandler, httptest.NewRequest(http.MethodGet, "/api/score?"+tt.query, nil), &got)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status %d: %s", tt.name, rec.Code, rec.Body.String())
+			continue
+		}
+		if got["state"] != tt.wantState || got["state_fips"] != tt.wantFIPS {
+			t.Errorf("%s: got state %v (%v), want %s (%s)", tt.name, got["state"], got["state_fips"], tt.wantState, tt.wantFIPS)
+		}
+	}
+}
+
+func TestScoreCoordinateHandlerRejectsFacility(t *testing.T) {
+	for _, query := range []string{"it_load_mw=NaN", "it_load_mw=Inf", "it_load_mw=1e308", "it_load_mw=-5", "cooling=seawater"} {
+		rec := httptest.NewRecorder()
+		ScoreCoordinateHandler(rec, httptest.NewRequest(http.MethodGet, "/api/score?lat=39&lng=-77.5&"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+	for _, body := range []string{`{"latitude":39,"longitude":-77.5,"facility":{"it_load_mw":1e308}}`,
+		`{"latitude":39,"longitude":-77.5,"facility":{"design_pue":1e300}}`} {
+		rec := httptest.NewRecorder()
+		ScoreCoordinateHandler(rec, httptest.NewRequest(http.MethodPost, "/api/score", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+const (
+	defaultSensitivityDelta   = 0.2
+	defaultSensitivityRange   = 0.5
+	defaultSensitivitySamples = 256
+	maxSensitivitySamples     = 1024
+)
+
+// SensitivityOptions controls the weight perturbations.
+type SensitivityOptions struct {
+	Delta   float64 `json:"delta,omitempty"`   // one-at-a-time relative change, default 0.2
+	Range   float64 `json:"range,omitempty"`   // Sobol multipliers are drawn from 1±Range, default 0.5
+	Samples int     `json:"samples,omitempty"` // Sobol base samples, default 256
+	Seed    *int64  `json:"seed,omitempty"`    // nil picks a seed from the clock
+}
+
+// WeightShift is a site's score and rank after scaling one weight by 1+Delta.
+type WeightShift struct {
+	Weight     string  `json:"weight"`
+	Delta      float64 `json:"delta"`
+	Score      float64 `json:"score"`
+	ScoreShift float64 `json:"score_shift"`
+	Rank       int     `json:"rank"`
+	RankShift  int     `json:"rank_shift"` // positive moves the site down the ranking
+}
+
+// SobolIndex is the share of a site's score variance explained by one weight alone
+// (first order) and together with its interactions (total order).
+type SobolIndex struct {
+	FirstOrder float64 `json:"first_order"`
+	TotalOrder float64 `json:"total_order"`
+}
+
+// SiteSensitivity is how one location's score and rank respond to the weights.
+type SiteSensitivity struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Score     float64 `json:"score"`
+	Rank      int     `json:"rank"`
+
+	OneAtATime []WeightShift         `json:"one_at_a_time"`
+	Sobol      map[string]SobolIndex `json:"sobol"`
+
+	RankP5        int     `json:"rank_p5"`
+	RankP50       int     `json:"rank_p50"`
+	RankP95       int     `json:"rank_p95"`
+	RankStability float64 `json:"rank_stability"` // share of Sobol samples that keep the baseline rank
+}
+
+// WeightSummary aggregates one weight's influence across every site.
+type WeightSummary struct {
+	Weight           string  `json:"weight"`
+	Value            float64 `json:"value"`
+	MeanAbsRankShift float64 `json:"mean_abs_rank_shift"`
+	MaxAbsRankShift  int     `json:"max_abs_rank_shift"`
+	MeanFirstOrder   float64 `json:"mean_first_order"`
+	MeanTotalOrder   float64 `json:"mean_total_order"`
+}
+
+// SensitivityReport is the result of AnalyseSensitivity. Sites are ordered by baseline rank.
+type SensitivityReport struct {
+	Profile scoring.Profile   `json:"scoring_profile"`
+	Delta   float64           `json:"delta"`
+	Range   float64           `json:"range"`
+	Samples int               `json:"samples"`
+	Seed    int64             `json:"seed"`
+	Summary []WeightSummary   `json:"summary"`
+	Sites   []SiteSensitivity `json:"sites"`
+}
+
+// AnalyseSensitivity scores every site once with profile, then re-weights the eco score one weight at
+// a time (±Delta) and with Saltelli/Jansen Sobol estimators over random weight multipliers.
+// Weights are renormalised to sum to 1 after every perturbation. Scores are compared
+// before clamping to 1-100 so that clamped sites still rank.
+func AnalyseSensitivity(sites []data.DatacenterLocation, nearby *data.SpatialIndex, facility data.FacilityProfile, profile scoring.Profile, opts SensitivityOptions) (SensitivityReport, error) {
+	if opts.Samples < 0 || opts.Samples > maxSensitivitySamples {
+		return SensitivityReport{}, fmt.Errorf("samples must be 0 (default) to %d", maxSensitivitySamples)
+	}
+	if !(opts.Delta >= 0 && opts.Delta < 1) || !(opts.Range >= 0 && opts.Range < 1) {
+		return SensitivityReport{}, fmt.Errorf("delta and range must be between 0 and 1")
+	}
+	opts = opts.withDefaults()
+
+	components := make([]ecoComponents, len(sites))
+	for i := range sites {
+		envData := data.GetEnvironmentalData(&sites[i], nearby)
+		cooling := calculateCooling(facility, envData.MonthlyClimate, envData.DatacenterDensity)
+		components[i] = calculateImpact(envData, facility, profile, cooling.AnnualPUE, cooling.AnnualWUE).components
+	}
+
+	base := weightValues(profile.Weights)
+	baseScores := scoreSites(components, profile, profile.Weights)
+	baseRanks := rankScores(baseScores)
+
+	report := SensitivityReport{
+		Profile: profile,
+		Delta:   opts.Delta,
+		Range:   opts.Range,
+		Samples: opts.Samples,
+		Seed:    *opts.Seed,
+		Sites:   make([]SiteSensitivity, len(sites)),
+		Summary: make([]WeightSummary, numEcoComponents),
+	}
+	for i, s := range sites {
+		report.Sites[i] = SiteSensitivity{
+			Name:      s.Name,
+			Latitude:  s.Latitude,
+			Longitude: s.Longitude,
+			Score:     baseScores[i],
+			Rank:      baseRanks[i],
+			Sobol:     make(map[string]SobolIndex, numEcoComponents),
+		}
+	}
+	for k, name := range ecoWeightNames {
+		report.Summary[k] = WeightSummary{Weight: name, Value: base[k]}
+	}
+
+	// One at a time
+	for k, name := range ecoWeightNames {
+		for _, delta := range []float64{-opts.Delta, opts.Delta} {
+			w := base
+			w[k] *= 1 + delta
+			scores := scoreSites(components, profile, normaliseWeights(w))
+			ranks := rankScores(scores)
+			for i := range sites {
+				shift := ranks[i] - baseRanks[i]
+				report.Sites[i].OneAtATime = append(report.Sites[i].OneAtATime, WeightShift{
+					Weight:     name,
+					Delta:      delta,
+					Score:      scores[i],
+					ScoreShift: scores[i] - baseScores[i],
+					Rank:       ranks[i],
+					RankShift:  shift,
+				})
+				abs := shift
+				if abs < 0 {
+					abs = -abs
+				}
+				report.Summary[k].MeanAbsRankShift += float64(abs)
+				if abs > report.Summary[k].MaxAbsRankShift {
+					report.Summary[k].MaxAbsRankShift = abs
+				}
+			}
+		}
+		if len(sites) > 0 {
+			report.Summary[k].MeanAbsRankShift /= float64(2 * len(sites))
+		}
+	}
+
+	// Sobol: A and B hold independent weight multipliers; AB_k is A with column k from B.
+	rng := rand.New(rand.NewSource(*opts.Seed))
+	n := opts.Samples
+	a := make([][numEcoComponents]float64, n)
+	b := make([][numEcoComponents]float64, n)
+	for j := 0; j < n; j++ {
+		for k := range base {
+			a[j][k] = 1 - opts.Range + 2*opts.Range*rng.Float64()
+			b[j][k] = 1 - opts.Range + 2*opts.Range*rng.Float64()
+		}
+	}
+	weightsFor := func(m [numEcoComponents]float64) scoring.Weights {
+		w := base
+		for k := range w {
+			w[k] *= m[k]
+		}
+		return normaliseWeights(w)
+	}
+
+	fA := make([][]float64, n)
+	fB := make([][]float64, n)
+	sampleRanks := make([][]int, len(sites))
+	for j := 0; j < n; j++ {
+		fA[j] = scoreSites(components, profile, weightsFor(a[j]))
+		fB[j] = scoreSites(components, profile, weightsFor(b[j]))
+		for _, scores := range [][]float64{fA[j], fB[j]} {
+			for i, r := range rankScores(scores) {
+				sampleRanks[i] = append(sampleRanks[i], r)
+			}
+		}
+	}
+
+	// Scores are centred on their mean in the first-order estimator: it is unbiased either
+	// way, but its sampling error grows with the mean, which dwarfs the variance here.
+	mean := make([]float64, len(sites))
+	variance := make([]float64, len(sites))
+	for i := range sites {
+		var sum, sumSq float64
+		for j := 0; j < n; j++ {
+			for _, v := range []float64{fA[j][i], fB[j][i]} {
+				sum += v
+				sumSq += v * v
+			}
+		}
+		mean[i] = sum / float64(2*n)
+		variance[i] = sumSq/float64(2*n) - mean[i]*mean[i]
+	}
+
+	for k, name := range ecoWeightNames {
+		first := make([]float64, len(sites))
+		total := make([]float64, len(sites))
+		for j := 0; j < n; j++ {
+			m := a[j]
+			m[k] = b[j][k]
+			fAB := scoreSites(components, profile, weightsFor(m))
+			for i := range sites {
+				first[i] += (fB[j][i] - mean[i]) * (fAB[i] - fA[j][i])
+				total[i] += (fA[j][i] - fAB[i]) * (fA[j][i] - fAB[i])
+			}
+		}
+		for i := range sites {
+			idx := SobolIndex{}
+			if variance[i] > 0 {
+				idx.FirstOrder = first[i] / float64(n) / variance[i]
+				idx.TotalOrder = total[i] / float64(2*n) / variance[i]
+			}
+			report.Sites[i].Sobol[name] = idx
+			report.Summary[k].MeanFirstOrder += idx.FirstOrder
+			report.Summary[k].MeanTotalOrder += idx.TotalOrder
+		}
+		if len(sites) > 0 {
+			report.Summary[k].MeanFirstOrder /= float64(len(sites))
+			report.Summary[k].MeanTotalOrder /= float64(len(sites))
+		}
+	}
+
+	for i := range sites {
+		ranks := sampleRanks[i]
+		same := 0
+		for _, r := range ranks {
+			if r == baseRanks[i] {
+				same++
+			}
+		}
+		sort.Ints(ranks)
+		s := &report.Sites[i]
+		s.RankP5 = rankPercentile(ranks, 5)
+		s.RankP50 = rankPercentile(ranks, 50)
+		s.RankP95 = rankPercentile(ranks, 95)
+		s.RankStability = float64(same) / float64(len(ranks))
+	}
+
+	sort.SliceStable(report.Sites, func(i, j int) bool { return report.Sites[i].Rank < report.Sites[j].Rank })
+	return report, nil
+}
+
+func (o SensitivityOptions) withDefaults() SensitivityOptions {
+	if o.Delta == 0 {
+		o.Delta = defaultSensitivityDelta
+	}
+	if o.Range == 0 {
+		o.Range = defaultSensitivityRange
+	}
+	if o.Samples == 0 {
+		o.Samples = defaultSensitivitySamples
+	}
+	if o.Seed == nil {
+		seed := time.Now().UnixNano()
+		o.Seed = &seed
+	}
+	return o
+}
+
+func normaliseWeights(w [numEcoComponents]float64) scoring.Weights {
+	var sum float64
+	for _, v := range w {
+		sum += v
+	}
+	for k := range w {
+		w[k] /= sum
+	}
+	return weightsFrom(w)
+}
+
+// scoreSites scores every site with profile's normalisers and the given weights.
+func scoreSites(components []ecoComponents, profile scoring.Profile, w scoring.Weights) []float64 {
+	profile.Weights = w
+	scores := make([]float64, len(components))
+	for i, c := range components {
+		scores[i] = calcEcoScore(c, profile)
+	}
+	return scores
+}
+
+// rankScores returns the 1-based rank of each score, highest first. Ties keep input order.
+func rankScores(scores []float64) []int {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	ranks := make([]int, len(scores))
+	for r, i := range order {
+		ranks[i] = r + 1
+	}
+	return ranks
+}
+
+func rankPercentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(math.Round(p/100*float64(len(sorted)-1)))]
+}
+
+// SensitivityHandler handles GET /api/sensitivity[?tier=..&delta=..&range=..&samples=..&seed=..&limit=..&profile=..&username=..]
+// over every candidate location. limit trims the returned sites to the best-ranked ones.
+func SensitivityHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	profile, err := scoringProfileFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	facility, ok := facilityFromQuery(r)
+	if !ok {
+		http.Error(w, "Unknown facility tier", http.StatusBadRequest)
+		return
+	}
+
+	var opts SensitivityOptions
+	for _, p := range []struct {
+		name string
+		dst  *float64
+	}{{"delta", &opts.Delta}, {"range", &opts.Range}} {
+		if v := q.Get(p.name); v != "" {
+			if *p.dst, err = strconv.ParseFloat(v, 64); err != nil || math.IsNaN(*p.dst) || math.IsInf(*p.dst, 0) {
+				http.Error(w, "Invalid "+p.name+" value", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if v := q.Get("samples"); v != "" {
+		if opts.Samples, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid samples value", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("seed"); v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid seed value", http.StatusBadRequest)
+			return
+		}
+		opts.Seed = &seed
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	locations, nearby, err := loadSites(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+
+	report, err := AnalyseSensitivity(locations, nearby, facility, profile, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit > 0 && limit < len(report.Sites) {
+		report.Sites = report.Sites[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
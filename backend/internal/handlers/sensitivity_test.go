@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+// sensitivitySites are candidate locations in different climates and grids.
+var sensitivitySites = []data.DatacenterLocation{
+	{Latitude: 39.0438, Longitude: -77.4874, Name: "Ashburn, VA"},
+	{Latitude: 33.4484, Longitude: -112.0740, Name: "Phoenix, AZ"},
+	{Latitude: 45.5946, Longitude: -121.1787, Name: "The Dalles, OR"},
+	{Latitude: 41.2565, Longitude: -95.9345, Name: "Omaha, NE"},
+	{Latitude: 32.7767, Longitude: -96.7970, Name: "Dallas, TX"},
+}
+
+func analyse(t *testing.T, profile scoring.Profile, opts SensitivityOptions) SensitivityReport {
+	t.Helper()
+	seed := int64(1)
+	opts.Seed = &seed
+	report, err := AnalyseSensitivity(sensitivitySites, nil, data.StandardFacility(), profile, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestAnalyseSensitivityOneAtATime(t *testing.T) {
+	report := analyse(t, scoring.DefaultProfile(), SensitivityOptions{Samples: 64})
+	for i, s := range report.Sites {
+		if s.Rank != i+1 {
+			t.Errorf("site %d has rank %d", i, s.Rank)
+		}
+		if len(s.OneAtATime) != 2*numEcoComponents {
+			t.Fatalf("%s: %d one-at-a-time shifts", s.Name, len(s.OneAtATime))
+		}
+		for _, shift := range s.OneAtATime {
+			if math.Abs(shift.Delta) != defaultSensitivityDelta || shift.RankShift != shift.Rank-s.Rank ||
+				math.Abs(shift.ScoreShift-(shift.Score-s.Score)) > 1e-9 {
+				t.Errorf("%s: %+v against score %.3f rank %d", s.Name, shift, s.Score, s.Rank)
+			}
+		}
+	}
+}
+
+func TestAnalyseSensitivitySobol(t *testing.T) {
+	profile := scoring.DefaultProfile()
+	profile.Weights = scoring.Weights{Carbon: 0.5, Water: 0.3, Temp: 0.2}
+	report := analyse(t, profile, SensitivityOptions{Samples: maxSensitivitySamples})
+
+	for _, s := range report.Sites {
+		var sum float64
+		for name, idx := range s.Sobol {
+			sum += idx.FirstOrder
+			if idx.TotalOrder < 0 {
+				t.Errorf("%s: %s total order %.3f", s.Name, name, idx.TotalOrder)
+			}
+		}
+		// The first-order indices share the variance. The score is close to additive in the
+		// weights, so they sum to about 1; allow for the estimator's sampling error.
+		if sum > 1.1 {
+			t.Errorf("%s: first-order indices sum to %.3f", s.Name, sum)
+		}
+		for _, name := range []string{"land", "social"} {
+			if idx := s.Sobol[name]; idx.FirstOrder != 0 || idx.TotalOrder != 0 {
+				t.Errorf("%s: zero-weight %s has sensitivity %+v", s.Name, name, idx)
+			}
+		}
+		for _, shift := range s.OneAtATime {
+			if (shift.Weight == "land" || shift.Weight == "social") && (shift.ScoreShift != 0 || shift.RankShift != 0) {
+				t.Errorf("%s: zero-weight %s moved the score by %.3f", s.Name, shift.Weight, shift.ScoreShift)
+			}
+		}
+		if !(s.RankP5 <= s.RankP50 && s.RankP50 <= s.RankP95) || s.RankStability < 0 || s.RankStability > 1 {
+			t.Errorf("%s: ranks %d/%d/%d, stability %.2f", s.Name, s.RankP5, s.RankP50, s.RankP95, s.RankStability)
+		}
+	}
+	for _, w := range report.Summary {
+		if (w.Weight == "land" || w.Weight == "social") && (w.MeanFirstOrder != 0 || w.MaxAbsRankShift != 0) {
+			t.Errorf("zero-weight summary %+v", w)
+		}
+	}
+}
+
+func TestAnalyseSensitivityRejects(t *testing.T) {
+	nan := math.NaN()
+	for _, opts := range []SensitivityOptions{
+		{Delta: -0.1}, {Delta: 1}, {Delta: nan}, {Range: nan}, {Range: math.Inf(1)}, {Samples: maxSensitivitySamples + 1},
+	} {
+		if _, err := AnalyseSensitivity(sensitivitySites, nil, data.StandardFacility(), scoring.DefaultProfile(), opts); err == nil {
+			t.Errorf("%+v accepted", opts)
+		}
+	}
+	for _, query := range []string{"delta=NaN", "range=NaN", "delta=Inf", "delta=1.5", "samples=-1"} {
+		rec := httptest.NewRecorder()
+		SensitivityHandler(rec, httptest.NewRequest(http.MethodGet, "/api/sensitivity?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
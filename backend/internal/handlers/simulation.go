@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
 	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
 )
 
 // Minimal constants for the demonstration.
@@ -28,16 +31,91 @@ type ClimateProjection struct {
 	DegradationLevel       string  `json:"degradation_level"`        // e.g., Low, Moderate, High, Severe
 }
 
+// Climate response models: "tcre" accumulates each facility's emissions and converts them
+// to warming; "fixed" adds a set °C per operating facility, inferred from its type, size
+// and region.
+const (
+	responseTCRE  = "tcre"
+	responseFixed = "fixed"
+)
+
+// maxTCREPerThousandGt bounds the tcre parameter, in °C per 1000 GtCO2: several times the
+// top of the IPCC AR6 likely range of 0.27-0.63.
+const maxTCREPerThousandGt = 5
+
+// Grid variants: "policy" cleans up each facility's grid electricity along its state's or
+// country's decarbonisation trajectory; "frozen" keeps today's grid intensity.
+const (
+	gridPolicy = "policy"
+	gridFrozen = "frozen"
+)
+
+// YearContribution is one facility's, or all facilities', contribution in one year.
+type YearContribution struct {
+	Year         int     `json:"year"`
+	GridFactor   float64 `json:"grid_factor,omitempty"` // grid intensity as a share of today's, per facility
+	Emissions    float64 `json:"emissions"`             // tonnes CO2e emitted that year
+	WaterUsage   float64 `json:"water_usage"`           // gallons consumed that year
+	Contribution float64 `json:"contribution"`          // extra °C
+}
+
+// FacilityContribution is one cart item's part of the data center contribution. Emissions
+// only count while the facility operates.
+type FacilityContribution struct {
+	Index            int                    `json:"index"` // position in the cart
+	Name             string                 `json:"name"`
+	Schedule         data.OperatingSchedule `json:"schedule"`
+	AnnualEmissions  float64                `json:"annual_emissions"`                 // tonnes CO2e/year at full load on today's grid
+	GridEmissions    float64                `json:"grid_emissions"`                   // the part of AnnualEmissions from grid electricity
+	GridPolicy       string                 `json:"grid_policy,omitempty"`            // policy grid only
+	AnnualWaterUsage float64                `json:"annual_water_usage"`               // gallons/year at full load
+	FullLoad         float64                `json:"full_load_contribution,omitempty"` // extra °C at full load, fixed model only
+	// CumulativeEmissions and CumulativeWaterUsage are the sums of the yearly values.
+	CumulativeEmissions  float64 `json:"cumulative_emissions"`   // tonnes CO2e
+	CumulativeWaterUsage float64 `json:"cumulative_water_usage"` // gallons
+	// Integrated is the sum of Series, in °C·years.
+	Integrated float64            `json:"integrated_contribution"`
+	Series     []YearContribution `json:"series"`
+}
+
+// GridVariant sums every facility's contribution under one grid assumption.
+type GridVariant struct {
+	Grid                 string             `json:"grid"`
+	CumulativeEmissions  float64            `json:"cumulative_emissions"`   // tonnes CO2e
+	CumulativeWaterUsage float64            `json:"cumulative_water_usage"` // gallons
+	TotalTimeToEnd       int                `json:"total_time_to_end"`
+	Series               []YearContribution `json:"series"`
+}
+
+// ScenarioInfo describes the baseline scenario a simulation ran against.
+type ScenarioInfo struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source,omitempty"`
+}
+
 // SimulationResponse is the overall response from the simulation endpoint.
 type SimulationResponse struct {
-	Username               string              `json:"username"`
-	WithDataCenters        []ClimateProjection `json:"with_data_centers"`
-	WithoutDataCenters     []ClimateProjection `json:"without_data_centers"`
-	TotalTimeToEnd         int                 `json:"total_time_to_end"`
-	TimeDatacentersRemoved int                 `json:"time_datacenters_removed"`
+	Username               string                 `json:"username"`
+	Scenario               ScenarioInfo           `json:"scenario"`
+	ResponseModel          string                 `json:"response_model"`
+	ClimateResponse        *data.ClimateResponse  `json:"climate_response,omitempty"` // tcre model only
+	Grid                   string                 `json:"grid"`                       // variant of the projections and facilities
+	GridVariants           []GridVariant          `json:"grid_variants"`
+	WithDataCenters        []ClimateProjection    `json:"with_data_centers"`
+	WithoutDataCenters     []ClimateProjection    `json:"without_data_centers"`
+	Facilities             []FacilityContribution `json:"facilities"`
+	TotalTimeToEnd         int                    `json:"total_time_to_end"`
+	TimeDatacentersRemoved int                    `json:"time_datacenters_removed"`
 }
 
-// GetUserClimateSimulationHandler handles GET /api/simulation?username=alice
+// GetUserClimateSimulationHandler handles GET /api/simulation?username=alice[&scenario=ssp2-4.5],
+// projecting against the named baseline scenario, or data.DefaultScenarioName. The response
+// parameter picks the tcre (default) or fixed model; for tcre, tcre overrides the coefficient
+// in °C per 1000 GtCO2 and decay=two-box lets emissions be drawn down over time. The grid
+// parameter picks the policy (default) or frozen grid for the projections; both are
+// summarised in grid_variants.
 func GetUserClimateSimulationHandler(w http.ResponseWriter, r *http.Request) {
 	addCORSHeaders(w)
 	if r.Method == http.MethodOptions {
@@ -54,6 +132,11 @@ func GetUserClimateSimulationHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing username query parameter", http.StatusBadRequest)
 		return
 	}
+	opts, err := simulationOptionsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// 1. Get or create a user cart
 	userCart, ok := cart.GetCart(username)
@@ -66,103 +149,298 @@ func GetUserClimateSimulationHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// 2. Calculate total data center contribution
-	dataCenterContribution := calcDataCenterContribution(userCart.Items)
-
+	// 2. Calculate each data center's contribution over its operating life
+	_, nearby, err := loadSites(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
 	var (
-		projectionsWithDC    []ClimateProjection
-		projectionsWithoutDC []ClimateProjection
-		totalTimeToEnd       int
-		totalTimeNoDC        int
+		facilities        []FacilityContribution
+		projectionsWithDC []ClimateProjection
+		totalTimeToEnd    int
+		variants          []GridVariant
 	)
+	items, err := scoreCartItems(userCart.Items, nearby)
+	if err != nil {
+		http.Error(w, "Invalid cart item: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, g := range []string{gridPolicy, gridFrozen} {
+		fs := calcFacilityContributions(items, opts.model, opts.response, g)
+		projections, timeToEnd := projectClimate(opts.scenario, fs)
+		variants = append(variants, sumGridVariant(g, fs, timeToEnd))
+		if g == opts.grid {
+			facilities, projectionsWithDC, totalTimeToEnd = fs, projections, timeToEnd
+		}
+	}
+	// Without Data Centers scenario (baseline only)
+	projectionsWithoutDC, totalTimeNoDC := projectClimate(opts.scenario, nil)
 
-	// Iterate over simulation years
+	resp := SimulationResponse{
+		Username:               username,
+		Scenario:               opts.scenarioInfo(),
+		ResponseModel:          opts.model,
+		ClimateResponse:        opts.climateResponse(),
+		Grid:                   opts.grid,
+		GridVariants:           variants,
+		WithDataCenters:        projectionsWithDC,
+		WithoutDataCenters:     projectionsWithoutDC,
+		Facilities:             facilities,
+		TotalTimeToEnd:         totalTimeToEnd,
+		TimeDatacentersRemoved: totalTimeNoDC - totalTimeToEnd,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ----------------------------------------------------------
+// Helper functions
+// ----------------------------------------------------------
+
+// projectClimate adds the facilities' contributions to the scenario baseline each year and
+// returns the projections and the years until survivability first falls to the threshold,
+// or the whole simulation period if it never does.
+func projectClimate(scenario *data.ClimateScenario, facilities []FacilityContribution) ([]ClimateProjection, int) {
+	projections := make([]ClimateProjection, 0, endYear-startYear+1)
+	timeToEnd := 0
 	for year := startYear; year <= endYear; year++ {
-		// Baseline from 1.2°C (2025) to 3.7°C (2100)
-		baselineTemp := getBaselineTemperature(year)
+		// Baseline temperature and fossil fuel reserves from the scenario
+		baseline := scenario.At(year)
 
-		// Fossil fuel fraction decays linearly from 1.0 to 0.2
-		fossilRes := getFossilFuelFraction(year)
+		// Facilities in operation that year
+		var dataCenterContribution float64
+		for _, f := range facilities {
+			dataCenterContribution += f.Series[year-startYear].Contribution
+		}
 
-		// With Data Centers scenario
-		totalTemp := baselineTemp + dataCenterContribution
-		surv := calcSurvivability(totalTemp, fossilRes)
-		degradation := determineDegradationLevel(totalTemp)
-		projDC := ClimateProjection{
+		totalTemp := baseline.Temperature + dataCenterContribution
+		surv := calcSurvivability(totalTemp, baseline.FossilFuelReserves)
+		projections = append(projections, ClimateProjection{
 			Year:                   year,
-			BaselineTemperature:    baselineTemp,
+			BaselineTemperature:    baseline.Temperature,
 			DataCenterContribution: dataCenterContribution,
 			TotalTemperature:       totalTemp,
-			FossilFuelReserves:     fossilRes,
+			FossilFuelReserves:     baseline.FossilFuelReserves,
 			Survivability:          int(math.Round(surv)),
-			DegradationLevel:       degradation,
-		}
-		projectionsWithDC = append(projectionsWithDC, projDC)
+			DegradationLevel:       determineDegradationLevel(totalTemp),
+		})
 
-		// Without Data Centers scenario (baseline only)
-		noDCtemp := baselineTemp
-		noDCsurv := calcSurvivability(noDCtemp, fossilRes)
-		noDCdegradation := determineDegradationLevel(noDCtemp)
-		projNoDC := ClimateProjection{
-			Year:                   year,
-			BaselineTemperature:    baselineTemp,
-			DataCenterContribution: 0,
-			TotalTemperature:       noDCtemp,
-			FossilFuelReserves:     fossilRes,
-			Survivability:          int(math.Round(noDCsurv)),
-			DegradationLevel:       noDCdegradation,
+		// Determine threshold crossing for survivability.
+		if timeToEnd == 0 && surv <= thresholdSurvivability {
+			timeToEnd = year - startYear
 		}
-		projectionsWithoutDC = append(projectionsWithoutDC, projNoDC)
+	}
+	// If threshold never crossed, set to maximum simulation period.
+	if timeToEnd == 0 {
+		timeToEnd = endYear - startYear
+	}
+	return projections, timeToEnd
+}
 
-		// Determine threshold crossing for survivability for with-DC scenario.
-		if totalTimeToEnd == 0 && surv <= thresholdSurvivability {
-			totalTimeToEnd = year - startYear
-		}
-		// And for the without-DC scenario.
-		if totalTimeNoDC == 0 && noDCsurv <= thresholdSurvivability {
-			totalTimeNoDC = year - startYear
+// sumGridVariant adds up the facilities' yearly emissions, water use and contributions.
+func sumGridVariant(grid string, facilities []FacilityContribution, timeToEnd int) GridVariant {
+	v := GridVariant{Grid: grid, TotalTimeToEnd: timeToEnd, Series: make([]YearContribution, 0, endYear-startYear+1)}
+	for year := startYear; year <= endYear; year++ {
+		y := YearContribution{Year: year}
+		for _, f := range facilities {
+			y.Emissions += f.Series[year-startYear].Emissions
+			y.WaterUsage += f.Series[year-startYear].WaterUsage
+			y.Contribution += f.Series[year-startYear].Contribution
 		}
+		v.Series = append(v.Series, y)
+		v.CumulativeEmissions += y.Emissions
+		v.CumulativeWaterUsage += y.WaterUsage
 	}
+	return v
+}
 
-	// If threshold never crossed, set to maximum simulation period.
-	if totalTimeToEnd == 0 {
-		totalTimeToEnd = endYear - startYear
+// simulationOptions are the query parameters shared by the simulation endpoints.
+type simulationOptions struct {
+	scenario *data.ClimateScenario
+	model    string
+	response data.ClimateResponse
+	grid     string
+}
+
+// simulationOptionsFromQuery reads the scenario, response, tcre, decay and grid parameters.
+func simulationOptionsFromQuery(r *http.Request) (simulationOptions, error) {
+	var opts simulationOptions
+	scenario, ok := data.Scenario(r.URL.Query().Get("scenario"))
+	if !ok {
+		return opts, fmt.Errorf("unknown scenario %q", r.URL.Query().Get("scenario"))
+	}
+	model, response, err := climateResponseFromQuery(r)
+	if err != nil {
+		return opts, err
+	}
+	grid := r.URL.Query().Get("grid")
+	switch grid {
+	case "":
+		grid = gridPolicy
+	case gridPolicy, gridFrozen:
+	default:
+		return opts, fmt.Errorf("unknown grid %q; use policy or frozen", grid)
 	}
-	if totalTimeNoDC == 0 {
-		totalTimeNoDC = endYear - startYear
+	return simulationOptions{scenario: scenario, model: model, response: response, grid: grid}, nil
+}
+
+func (o simulationOptions) scenarioInfo() ScenarioInfo {
+	return ScenarioInfo{
+		Name:        o.scenario.Name,
+		Title:       o.scenario.Title,
+		Description: o.scenario.Description,
+		Source:      o.scenario.Source,
 	}
+}
 
-	resp := SimulationResponse{
-		Username:               username,
-		WithDataCenters:        projectionsWithDC,
-		WithoutDataCenters:     projectionsWithoutDC,
-		TotalTimeToEnd:         totalTimeToEnd,
-		TimeDatacentersRemoved: totalTimeNoDC - totalTimeToEnd,
+// climateResponse returns the TCRE response for the response, or nil for the fixed model.
+func (o simulationOptions) climateResponse() *data.ClimateResponse {
+	if o.model != responseTCRE {
+		return nil
 	}
+	response := o.response
+	return &response
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+// climateResponseFromQuery reads the response, tcre and decay parameters.
+func climateResponseFromQuery(r *http.Request) (string, data.ClimateResponse, error) {
+	q := r.URL.Query()
+	response := data.ClimateResponse{TCRE: data.DefaultTCRE}
+	model := q.Get("response")
+	switch model {
+	case "":
+		model = responseTCRE
+	case responseTCRE, responseFixed:
+	default:
+		return "", response, fmt.Errorf("unknown response model %q; use tcre or fixed", model)
+	}
+	if v := q.Get("tcre"); v != "" {
+		perThousandGt, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(perThousandGt > 0 && perThousandGt <= maxTCREPerThousandGt) {
+			return "", response, fmt.Errorf("invalid tcre value; use °C per 1000 GtCO2, above 0 and up to %d", maxTCREPerThousandGt)
+		}
+		response.TCRE = perThousandGt / 1e12
+	}
+	switch q.Get("decay") {
+	case "", "none":
+	case "two-box":
+		response.Decay = data.TwoBoxDecay
+	default:
+		return "", response, fmt.Errorf("unknown decay %q; use none or two-box", q.Get("decay"))
+	}
+	return model, response, nil
 }
 
-// ----------------------------------------------------------
-// Helper functions
-// ----------------------------------------------------------
+// calcFacilityContributions computes the yearly contribution of each data center in the
+// user's cart; items must already be scored with scoreCartItems.
+//
+// Each facility emits its annual emissions scaled by its load factor, so it only counts
+// while it runs. In the policy variant its grid electricity follows the policy grid's
+// trajectory. The tcre model turns those emissions into warming with response.
+func calcFacilityContributions(items []data.DatacenterLocation, model string, response data.ClimateResponse, grid string) []FacilityContribution {
+	trajectories := data.GridTrajectories()
+	facilities := make([]FacilityContribution, 0, len(items))
+	for i, dc := range items {
+		f := FacilityContribution{
+			Index:  i,
+			Name:   dc.Name,
+			Series: make([]YearContribution, 0, endYear-startYear+1),
+		}
+		f.AnnualEmissions, f.GridEmissions = dc.CarbonImpact, gridEmissions(dc)
+		f.AnnualWaterUsage = dc.WaterUsage
+		if dc.Schedule != nil {
+			f.Schedule = *dc.Schedule
+		}
+		if model == responseFixed {
+			f.FullLoad = calcDataCenterContribution(dc)
+		}
+		place := data.ResolvePlace(&dc)
+		if grid == gridPolicy {
+			f.GridPolicy = trajectories.For(place).Policy
+		}
 
-// calcDataCenterContribution computes the overall damage contribution from all data centers in the user's cart.
-func calcDataCenterContribution(items []data.DatacenterLocation) float64 {
-	var total float64
-	for _, dc := range items {
-		// 1) Identify DC type from name or notes.
-		dcType := inferDCType(dc.Name, dc.Notes)
-		// 2) Identify size from landPrice or notes.
-		size := inferDCSize(dc.LandPrice)
-		// 3) Identify region factor from lat/long.
-		region := inferRegion(dc.Latitude, dc.Longitude)
-		// 4) Calculate final emission contribution.
-		emission := dataCenterEmission(dcType, size, region)
-		total += emission
-	}
-	return total
+		emissions := make([]float64, 0, endYear-startYear+1)
+		factors := make([]float64, 0, endYear-startYear+1)
+		for year := startYear; year <= endYear; year++ {
+			factor := 1.0
+			if grid == gridPolicy {
+				factor = trajectories.Factor(place, year)
+			}
+			e := (f.AnnualEmissions - f.GridEmissions + f.GridEmissions*factor) * f.Schedule.LoadFactor(year)
+			emissions = append(emissions, e)
+			factors = append(factors, factor)
+			f.CumulativeEmissions += e
+		}
+		warming, _ := response.Warming(emissions)
+		for t, e := range emissions {
+			c := warming[t]
+			if model == responseFixed {
+				// The fixed contribution follows the facility's emissions relative to full
+				// load on today's grid.
+				c = f.FullLoad * f.Schedule.LoadFactor(startYear+t)
+				if f.AnnualEmissions > 0 {
+					c = f.FullLoad * e / f.AnnualEmissions
+				}
+			}
+			water := f.AnnualWaterUsage * f.Schedule.LoadFactor(startYear+t)
+			f.Series = append(f.Series, YearContribution{Year: startYear + t, GridFactor: factors[t], Emissions: e, WaterUsage: water, Contribution: c})
+			f.Integrated += c
+			f.CumulativeWaterUsage += water
+		}
+		facilities = append(facilities, f)
+	}
+	return facilities
+}
+
+// scoreCartItems scores every cart item with scoreCartItem.
+func scoreCartItems(items []data.DatacenterLocation, nearby *data.SpatialIndex) ([]data.DatacenterLocation, error) {
+	scored := make([]data.DatacenterLocation, len(items))
+	for i, dc := range items {
+		var err error
+		if scored[i], err = scoreCartItem(dc, nearby); err != nil {
+			return nil, err
+		}
+	}
+	return scored, nil
+}
+
+// scoreCartItem scores the cart item on the server with its facility, filled in from its
+// tier, or the Standard tier. Metrics stored with the item came from the client and are
+// replaced.
+func scoreCartItem(dc data.DatacenterLocation, nearby *data.SpatialIndex) (data.DatacenterLocation, error) {
+	facility := data.StandardFacility()
+	if dc.Facility != nil {
+		var err error
+		if facility, err = dc.Facility.WithDefaults(); err != nil {
+			return dc, fmt.Errorf("%s: %w", dc.Name, err)
+		}
+	}
+	CalculateResearchBasedMetrics(&dc, nearby, facility, scoring.DefaultProfile())
+	return dc, nil
+}
+
+// gridEmissions returns the part of the item's CarbonImpact from grid electricity. Backup
+// generation burns its own fuel; an item scored without a facility is all grid.
+func gridEmissions(dc data.DatacenterLocation) float64 {
+	if dc.Facility == nil {
+		return dc.CarbonImpact
+	}
+	// MWh of backup generation at kg/kWh is tonnes.
+	backup := dc.Facility.ITLoadMW * dc.Facility.BackupHours() * dc.Facility.BackupEmissionsIntensity()
+	return math.Max(0, dc.CarbonImpact-backup)
+}
+
+// calcDataCenterContribution computes the damage contribution of one data center at full load.
+func calcDataCenterContribution(dc data.DatacenterLocation) float64 {
+	// 1) Identify DC type from name or notes.
+	dcType := inferDCType(dc.Name, dc.Notes)
+	// 2) Identify size from landPrice or notes.
+	size := inferDCSize(dc.LandPrice)
+	// 3) Identify region factor from lat/long.
+	region := inferRegion(dc.Latitude, dc.Longitude)
+	// 4) Calculate final emission contribution.
+	return dataCenterEmission(dcType, size, region)
 }
 
 // dataCenterEmission returns a small fraction of °C contributed by one data center.
@@ -206,9 +484,12 @@ func inferDCType(name, notes string) string {
 	return "Standard"
 }
 
-// inferDCSize checks if the landPrice mentions "2.5M" and returns "large"; otherwise "medium".
+// largeDCLandPrice is the top-of-range land price at which a site is treated as a large build.
+const largeDCLandPrice = 2500000
+
+// inferDCSize returns "large" when the landPrice range reaches largeDCLandPrice; otherwise "medium".
 func inferDCSize(landPrice string) string {
-	if strings.Contains(strings.ToLower(landPrice), "2.5m") {
+	if r, err := data.ParsePriceRange(landPrice); err == nil && r.Max >= largeDCLandPrice {
 		return "large"
 	}
 	return "medium"
@@ -224,16 +505,20 @@ func inferRegion(lat, lng float64) string {
 	return "average"
 }
 
-// getBaselineTemperature linearly interpolates from 1.2°C in 2025 to 3.7°C in 2100.
-func getBaselineTemperature(year int) float64 {
-	frac := float64(year-startYear) / float64(endYear-startYear)
-	return 1.2 + frac*(3.7-1.2)
-}
-
-// getFossilFuelFraction linearly decays from 1.0 in 2025 to 0.2 in 2100.
-func getFossilFuelFraction(year int) float64 {
-	frac := float64(year-startYear) / float64(endYear-startYear)
-	return 1.0 - frac*(1.0-0.2)
+// ScenariosHandler handles GET /api/scenarios, listing the baseline scenarios for
+// /api/simulation?scenario=.. with their yearly values.
+func ScenariosHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data.Scenarios())
 }
 
 // calcSurvivability computes survivability as a function of temperature and fossil fuel reserves.
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/cart"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+)
+
+// ashburn is a planned Standard-tier site in northern Virginia.
+func ashburn(schedule *data.OperatingSchedule) data.DatacenterLocation {
+	return data.DatacenterLocation{
+		Latitude:    39.0438,
+		Longitude:   -77.4874,
+		Name:        "Simulated Ashburn site",
+		LandPrice:   "$500,000-1,500,000/acre",
+		Electricity: "$0.08/kWh",
+		Schedule:    schedule,
+	}
+}
+
+// withCart gives username a cart holding items for the length of the test.
+func withCart(t *testing.T, username string, items ...data.DatacenterLocation) {
+	t.Helper()
+	for _, item := range items {
+		if err := cart.AddToCart(username, item, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { cart.DeleteCart(username) })
+}
+
+func simulate(t *testing.T, query string) SimulationResponse {
+	t.Helper()
+	var resp SimulationResponse
+	rec := serveJSON(t, GetUserClimateSimulationHandler, httptest.NewRequest(http.MethodGet, "/api/simulation?"+query, nil), &resp)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: status %d: %s", query, rec.Code, rec.Body.String())
+	}
+	return resp
+}
+
+func TestSimulationFollowsSchedule(t *testing.T) {
+	withCart(t, "sim-schedule", ashburn(&data.OperatingSchedule{CommissionYear: 2030, DecommissionYear: 2040, RampUpYears: 2}))
+	resp := simulate(t, "username=sim-schedule&grid=frozen")
+	if len(resp.Facilities) != 1 {
+		t.Fatalf("got %d facilities, want 1", len(resp.Facilities))
+	}
+	f := resp.Facilities[0]
+	if f.AnnualEmissions <= 0 {
+		t.Fatalf("facility scored with no emissions: %+v", f)
+	}
+	for _, y := range f.Series {
+		want := f.Schedule.LoadFactor(y.Year) * f.AnnualEmissions
+		if math.Abs(y.Emissions-want) > 1e-6*f.AnnualEmissions {
+			t.Errorf("%d: emissions %.1f, want %.1f", y.Year, y.Emissions, want)
+		}
+	}
+	for year, factor := range map[int]float64{2029: 0, 2030: 0.5, 2031: 1, 2039: 1, 2040: 0} {
+		if got := f.Series[year-startYear].Emissions; math.Abs(got-factor*f.AnnualEmissions) > 1e-6*f.AnnualEmissions {
+			t.Errorf("%d: emissions %.1f, want %.0f%% of %.1f", year, got, factor*100, f.AnnualEmissions)
+		}
+	}
+	if got := resp.WithDataCenters[2029-startYear].DataCenterContribution; got != 0 {
+		t.Errorf("contribution %g before commissioning", got)
+	}
+}
+
+func TestSimulationRejectsBadSchedule(t *testing.T) {
+	err := cart.AddToCart("sim-bad-schedule", ashburn(&data.OperatingSchedule{CommissionYear: 2040, DecommissionYear: 2030}), 0)
+	if err == nil {
+		cart.DeleteCart("sim-bad-schedule")
+		t.Error("decommissioning before commissioning was accepted")
+	}
+	if _, ok := cart.GetCart("sim-bad-schedule"); ok {
+		cart.DeleteCart("sim-bad-schedule")
+		t.Error("rejected item left an empty cart behind")
+	}
+}
+
+func TestSimulationScenarios(t *testing.T) {
+	withCart(t, "sim-scenarios", ashburn(nil))
+	tests := []struct {
+		scenario  string
+		wantName  string
+		final2100 float64
+	}{
+		{"", data.DefaultScenarioName, 3.7},
+		{"ssp1-2.6", "ssp1-2.6", 1.76},
+		{"ssp2-4.5", "ssp2-4.5", 2.82},
+		{"ssp5-8.5", "ssp5-8.5", 4.95},
+	}
+	for _, tt := range tests {
+		resp := simulate(t, "username=sim-scenarios&scenario="+tt.scenario)
+		if resp.Scenario.Name != tt.wantName {
+			t.Errorf("scenario %q ran as %q", tt.scenario, resp.Scenario.Name)
+		}
+		last := len(resp.WithoutDataCenters) - 1
+		if got := resp.WithoutDataCenters[last].BaselineTemperature; math.Abs(got-tt.final2100) > 1e-9 {
+			t.Errorf("%s: baseline in %d is %.2f °C, want %.2f", tt.wantName, resp.WithoutDataCenters[last].Year, got, tt.final2100)
+		}
+		// Both projections share the scenario's baseline.
+		for i, p := range resp.WithDataCenters {
+			if p.BaselineTemperature != resp.WithoutDataCenters[i].BaselineTemperature {
+				t.Errorf("%s: %d baselines differ", tt.wantName, p.Year)
+				break
+			}
+		}
+	}
+
+	rec := serveJSON(t, GetUserClimateSimulationHandler, httptest.NewRequest(http.MethodGet, "/api/simulation?username=sim-scenarios&scenario=rcp-unknown", nil), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown scenario: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	var scenarios []data.ClimateScenario
+	serveJSON(t, ScenariosHandler, httptest.NewRequest(http.MethodGet, "/api/scenarios", nil), &scenarios)
+	if len(scenarios) < len(tests) {
+		t.Errorf("/api/scenarios lists %d scenarios, want at least %d", len(scenarios), len(tests))
+	}
+}
+
+func TestSimulationClimateResponse(t *testing.T) {
+	withCart(t, "sim-tcre", ashburn(&data.OperatingSchedule{DecommissionYear: 2060}))
+
+	tcre := simulate(t, "username=sim-tcre&grid=frozen")
+	if tcre.ResponseModel != responseTCRE || tcre.ClimateResponse == nil || tcre.ClimateResponse.TCRE != data.DefaultTCRE {
+		t.Fatalf("default response is %q with %+v", tcre.ResponseModel, tcre.ClimateResponse)
+	}
+	f := tcre.Facilities[0]
+	cumulative := 0.0
+	for _, y := range f.Series {
+		cumulative += y.Emissions
+		if want := data.DefaultTCRE * cumulative; math.Abs(y.Contribution-want) > 1e-9*math.Max(want, 1e-12) {
+			t.Errorf("%d: contribution %g, want TCRE × cumulative emissions %g", y.Year, y.Contribution, want)
+			break
+		}
+	}
+	if last := f.Series[len(f.Series)-1].Contribution; last != f.Series[2060-startYear].Contribution {
+		t.Errorf("warming changed after the facility closed without decay: %g", last)
+	}
+
+	doubled := simulate(t, "username=sim-tcre&grid=frozen&tcre=0.9")
+	if got, want := doubled.Facilities[0].Integrated, 2*f.Integrated; math.Abs(got-want) > 1e-9*want {
+		t.Errorf("tcre=0.9 integrated %g, want %g", got, want)
+	}
+
+	decayed := simulate(t, "username=sim-tcre&grid=frozen&decay=two-box")
+	series := decayed.Facilities[0].Series
+	if closed, last := series[2060-startYear].Contribution, series[len(series)-1].Contribution; !(last < closed) {
+		t.Errorf("two-box decay: warming %g in %d is not below %g at closing", last, endYear, closed)
+	}
+
+	fixed := simulate(t, "username=sim-tcre&grid=frozen&response=fixed")
+	if fixed.ClimateResponse != nil || fixed.Facilities[0].FullLoad <= 0 {
+		t.Errorf("fixed response: climate_response %+v, full load %g", fixed.ClimateResponse, fixed.Facilities[0].FullLoad)
+	}
+	if got := fixed.Facilities[0].Series[2030-startYear].Contribution; math.Abs(got-fixed.Facilities[0].FullLoad) > 1e-12 {
+		t.Errorf("fixed response: contribution %g, want full load %g", got, fixed.Facilities[0].FullLoad)
+	}
+
+	for _, query := range []string{"response=linear", "tcre=-1", "tcre=abc", "tcre=NaN", "tcre=Inf", "tcre=100", "decay=three-box"} {
+		rec := serveJSON(t, GetUserClimateSimulationHandler, httptest.NewRequest(http.MethodGet, "/api/simulation?username=sim-tcre&"+query, nil), nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestSimulationGridVariants(t *testing.T) {
+	withCart(t, "sim-grid", ashburn(nil), ashburn(&data.OperatingSchedule{CommissionYear: 2035}))
+
+	policy := simulate(t, "username=sim-grid")
+	frozen := simulate(t, "username=sim-grid&grid=frozen")
+	if policy.Grid != gridPolicy || frozen.Grid != gridFrozen {
+		t.Fatalf("grids %q and %q", policy.Grid, frozen.Grid)
+	}
+	if len(policy.GridVariants) != 2 || policy.GridVariants[0].Grid != gridPolicy || policy.GridVariants[1].Grid != gridFrozen {
+		t.Fatalf("grid variants %+v", policy.GridVariants)
+	}
+	p, f := policy.GridVariants[0], policy.GridVariants[1]
+	if !(p.CumulativeEmissions < f.CumulativeEmissions) {
+		t.Errorf("policy grid emits %g, not less than frozen %g", p.CumulativeEmissions, f.CumulativeEmissions)
+	}
+	if p.CumulativeWaterUsage != f.CumulativeWaterUsage {
+		t.Errorf("water differs between grids: %g and %g", p.CumulativeWaterUsage, f.CumulativeWaterUsage)
+	}
+	if frozen.GridVariants[1].CumulativeEmissions != f.CumulativeEmissions {
+		t.Errorf("frozen variant differs between requests")
+	}
+
+	for i := range policy.Facilities {
+		pf, ff := policy.Facilities[i], frozen.Facilities[i]
+		if pf.AnnualEmissions != ff.AnnualEmissions || pf.GridEmissions != ff.GridEmissions {
+			t.Errorf("facility %d scored differently per grid: %g/%g and %g/%g", i, pf.AnnualEmissions, pf.GridEmissions, ff.AnnualEmissions, ff.GridEmissions)
+		}
+		if pf.GridPolicy == "" || ff.GridPolicy != "" {
+			t.Errorf("facility %d grid policy %q (policy) and %q (frozen)", i, pf.GridPolicy, ff.GridPolicy)
+		}
+		previous := 1.0
+		for _, y := range pf.Series {
+			if y.GridFactor > previous+1e-12 || y.GridFactor < 0 {
+				t.Errorf("facility %d: grid factor %g in %d after %g", i, y.GridFactor, y.Year, previous)
+				break
+			}
+			previous = y.GridFactor
+		}
+		for _, y := range ff.Series {
+			if y.GridFactor != 1 {
+				t.Errorf("facility %d: frozen grid factor %g in %d", i, y.GridFactor, y.Year)
+				break
+			}
+		}
+	}
+
+	rec := serveJSON(t, GetUserClimateSimulationHandler, httptest.NewRequest(http.MethodGet, "/api/simulation?username=sim-grid&grid=clean", nil), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown grid: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
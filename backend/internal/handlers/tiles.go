@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/tiles"
+)
+
+// Tile layers: existing datacenters, and the candidate sites of the possible locations CSV
+// or of an uploaded dataset.
+const (
+	tileLayerDatacenters = "datacenters"
+	tileLayerCandidates  = "candidates"
+)
+
+// tileIndexKey identifies the rows a tile index was built from.
+type tileIndexKey struct {
+	layer, region, dataset string
+}
+
+type cachedTileIndex struct {
+	first *data.DatacenterLocation // identity of the source rows
+	size  int
+	index *tiles.Index
+}
+
+var (
+	tileIndexes   = make(map[tileIndexKey]cachedTileIndex)
+	tileIndexesMu sync.Mutex
+)
+
+// tileIndex returns the index over rows, rebuilding it when a reload or upload has replaced
+// the rows it was built from. rows must not be modified.
+func tileIndex(key tileIndexKey, rows []data.DatacenterLocation) *tiles.Index {
+	var first *data.DatacenterLocation
+	if len(rows) > 0 {
+		first = &rows[0]
+	}
+	tileIndexesMu.Lock()
+	defer tileIndexesMu.Unlock()
+	if c, ok := tileIndexes[key]; ok && c.first == first && c.size == len(rows) {
+		return c.index
+	}
+
+	points := make([]tiles.Point, len(rows))
+	for i := range rows {
+		points[i] = tiles.Point{Lat: rows[i].Latitude, Lng: rows[i].Longitude, Properties: tileProperties(&rows[i])}
+	}
+	index := tiles.NewIndex(points)
+	tileIndexes[key] = cachedTileIndex{first: first, size: len(rows), index: index}
+	return index
+}
+
+// tileProperties are the feature properties of a location. Vector tiles have no lists, so
+// hyperscalers are joined into one string.
+func tileProperties(loc *data.DatacenterLocation) map[string]interface{} {
+	props := map[string]interface{}{"name": loc.Name}
+	for key, value := range map[string]string{
+		"land_price":   loc.LandPrice,
+		"electricity":  loc.Electricity,
+		"notes":        loc.Notes,
+		"country":      loc.Country,
+		"hyperscalers": strings.Join(loc.Hyperscalers, ", "),
+	} {
+		if value != "" {
+			props[key] = value
+		}
+	}
+	if loc.SubmarineCable {
+		props["submarine_cable"] = true
+	}
+	if loc.GovernmentPresence {
+		props["government_presence"] = true
+	}
+	return props
+}
+
+// parseTilePath reads "{layer}/{z}/{x}/{y}.mvt".
+func parseTilePath(path string) (layer string, z, x, y int, err error) {
+	layer, coords, ok := strings.Cut(path, "/")
+	if !ok {
+		return "", 0, 0, 0, fmt.Errorf("tile paths are /tiles/{layer}/{z}/{x}/{y}.mvt")
+	}
+	z, x, y, err = parseTileCoords(coords, ".mvt")
+	return layer, z, x, y, err
+}
+
+// parseTileCoords reads "{z}/{x}/{y}" followed by ext.
+func parseTileCoords(path, ext string) (z, x, y int, err error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || !strings.HasSuffix(parts[2], ext) {
+		return 0, 0, 0, fmt.Errorf("tile paths end in /{z}/{x}/{y}%s", ext)
+	}
+	coords := [3]int{}
+	for i, s := range []string{parts[0], parts[1], strings.TrimSuffix(parts[2], ext)} {
+		if coords[i], err = strconv.Atoi(s); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid tile coordinate %q", s)
+		}
+	}
+	z, x, y = coords[0], coords[1], coords[2]
+	if z < 0 || z > tiles.MaxZoom {
+		return 0, 0, 0, fmt.Errorf("zoom must be 0-%d", tiles.MaxZoom)
+	}
+	if n := 1 << z; x < 0 || x >= n || y < 0 || y >= n {
+		return 0, 0, 0, fmt.Errorf("tile %d/%d/%d does not exist", z, x, y)
+	}
+	return z, x, y, nil
+}
+
+// TilesHandler handles GET /tiles/{layer}/{z}/{x}/{y}.mvt[?region=..&dataset=..], serving
+// the datacenters or candidates layer as a Mapbox Vector Tile. Points are clustered up to
+// zoom tiles.ClusterMaxZoom; cluster features carry cluster=true and point_count.
+func TilesHandler(w http.ResponseWriter, r *http.Request) {
+	addCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	layer, z, x, y, err := parseTilePath(strings.TrimPrefix(r.URL.Path, "/tiles/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	registry, err := siteRegistry(r)
+	if err != nil {
+		writeSitesError(w, err)
+		return
+	}
+	key := tileIndexKey{layer: layer, region: strings.ToUpper(r.URL.Query().Get("region"))}
+
+	var rows []data.DatacenterLocation
+	switch layer {
+	case tileLayerDatacenters:
+		snapshot, err := registry.Snapshot()
+		if err != nil {
+			writeSitesError(w, err)
+			return
+		}
+		rows = snapshot.ExistingDatacenters.Rows
+	case tileLayerCandidates:
+		key.dataset = r.URL.Query().Get("dataset")
+		if rows, err = registry.CandidateSites(key.dataset); err != nil {
+			writeSitesError(w, err)
+			return
+		}
+	default:
+		http.Error(w, "Unknown tile layer "+layer, http.StatusNotFound)
+		return
+	}
+
+	features := tileIndex(key, rows).Tile(z, x, y)
+	w.Header().Set("Content-Type", tiles.ContentType)
+	w.Write(tiles.Encode(tiles.Layer{Name: layer, Features: features}))
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+const (
+	defaultUncertaintySamples = 1000
+	maxUncertaintySamples     = 20000
+)
+
+// UncertaintyOptions controls the Monte Carlo run. Spreads are standard deviations;
+// unset spreads use the defaults below, and 0 holds the input fixed.
+type UncertaintyOptions struct {
+	Samples int    `json:"samples,omitempty"`
+	Seed    *int64 `json:"seed,omitempty"` // nil picks a seed from the clock
+
+	GridIntensitySpread *float64 `json:"grid_intensity_spread,omitempty"` // log-normal sigma
+	WaterStressSpread   *float64 `json:"water_stress_spread,omitempty"`   // index points
+	PUESpread           *float64 `json:"pue_spread,omitempty"`            // log-normal sigma of the overhead above 1
+	WUESpread           *float64 `json:"wue_spread,omitempty"`            // log-normal sigma
+}
+
+const (
+	defaultGridIntensitySpread = 0.15
+	defaultWaterStressSpread   = 0.5
+	defaultPUESpread           = 0.2
+	defaultWUESpread           = 0.25
+
+	// maxLogSpread caps the log-normal sigmas: at 2 a 5-sigma draw is a factor of e^10,
+	// and much larger spreads overflow the model.
+	maxLogSpread = 2
+	// maxWaterStressSpread is the width of the 0-5 water stress index.
+	maxWaterStressSpread = 5
+)
+
+// Distribution summarises the samples of one metric.
+type Distribution struct {
+	Mean float64 `json:"mean"`
+	P5   float64 `json:"p5"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+}
+
+// UncertaintyResult holds the distributions of the headline metrics.
+type UncertaintyResult struct {
+	Samples      int          `json:"samples"`
+	Seed         int64        `json:"seed"`
+	EcoScore     Distribution `json:"eco_score"`
+	CarbonImpact Distribution `json:"carbon_impact"`
+	WaterUsage   Distribution `json:"water_usage"`
+	TempIncrease Distribution `json:"temp_increase"`
+}
+
+// EstimateUncertainty samples grid intensity, water stress, PUE and WUE around their
+// modelled values and runs the impact model once per sample across all CPUs. The draws
+// all come from one source seeded up front, so a seed always gives the same result.
+func EstimateUncertainty(loc *data.DatacenterLocation, nearby *data.SpatialIndex, facility data.FacilityProfile, profile scoring.Profile, opts UncertaintyOptions) (UncertaintyResult, error) {
+	if opts.Samples < 0 || opts.Samples > maxUncertaintySamples {
+		return UncertaintyResult{}, fmt.Errorf("samples must be 0 (default) to %d", maxUncertaintySamples)
+	}
+	opts = opts.withDefaults()
+	gridSpread, waterSpread := *opts.GridIntensitySpread, *opts.WaterStressSpread
+	pueSpread, wueSpread := *opts.PUESpread, *opts.WUESpread
+	for _, f := range []struct {
+		name        string
+		spread, max float64
+	}{
+		{"grid_intensity_spread", gridSpread, maxLogSpread},
+		{"water_stress_spread", waterSpread, maxWaterStressSpread},
+		{"pue_spread", pueSpread, maxLogSpread},
+		{"wue_spread", wueSpread, maxLogSpread},
+	} {
+		if !(f.spread >= 0 && f.spread <= f.max) {
+			return UncertaintyResult{}, fmt.Errorf("%s must be between 0 and %g", f.name, f.max)
+		}
+	}
+
+	envData := data.GetEnvironmentalData(loc, nearby)
+	cooling := calculateCooling(facility, envData.MonthlyClimate, envData.DatacenterDensity)
+
+	// Standard normal draws for grid intensity, water stress, PUE and WUE, per sample.
+	rng := rand.New(rand.NewSource(*opts.Seed))
+	draws := make([][4]float64, opts.Samples)
+	for i := range draws {
+		draws[i] = [4]float64{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+	}
+
+	samples := make([]impactMetrics, opts.Samples)
+	workers := runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(samples); i += workers {
+				d := draws[i]
+				env := envData
+				env.GridEmissionsIntensity *= math.Exp(d[0] * gridSpread)
+				env.WaterScarcityIndex = math.Max(0, math.Min(5, env.WaterScarcityIndex+d[1]*waterSpread))
+				pue := 1 + (cooling.AnnualPUE-1)*math.Exp(d[2]*pueSpread)
+				wue := cooling.AnnualWUE * math.Exp(d[3]*wueSpread)
+
+				samples[i] = calculateImpact(env, facility, profile, pue, wue)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	result := UncertaintyResult{Samples: opts.Samples, Seed: *opts.Seed}
+	result.EcoScore = summarise(samples, func(m impactMetrics) float64 { return m.ecoScore })
+	result.CarbonImpact = summarise(samples, func(m impactMetrics) float64 { return m.carbonImpact })
+	result.WaterUsage = summarise(samples, func(m impactMetrics) float64 { return m.waterUsage })
+	result.TempIncrease = summarise(samples, func(m impactMetrics) float64 { return m.tempIncrease })
+	return result, nil
+}
+
+func (o UncertaintyOptions) withDefaults() UncertaintyOptions {
+	if o.Samples == 0 {
+		o.Samples = defaultUncertaintySamples
+	}
+	if o.Seed == nil {
+		seed := time.Now().UnixNano()
+		o.Seed = &seed
+	}
+	for _, f := range []struct {
+		spread **float64
+		def    float64
+	}{
+		{&o.GridIntensitySpread, defaultGridIntensitySpread},
+		{&o.WaterStressSpread, defaultWaterStressSpread},
+		{&o.PUESpread, defaultPUESpread},
+		{&o.WUESpread, defaultWUESpread},
+	} {
+		if *f.spread == nil {
+			def := f.def
+			*f.spread = &def
+		}
+	}
+	return o
+}
+
+func summarise(samples []impactMetrics, metric func(impactMetrics) float64) Distribution {
+	values := make([]float64, len(samples))
+	var sum float64
+	for i, s := range samples {
+		values[i] = metric(s)
+		sum += values[i]
+	}
+	sort.Float64s(values)
+	return Distribution{
+		Mean: sum / float64(len(values)),
+		P5:   percentile(values, 5),
+		P50:  percentile(values, 50),
+		P95:  percentile(values, 95),
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
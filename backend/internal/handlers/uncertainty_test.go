@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/data"
+	"github.com/Samhith-k/data-center-ecology-map/backend/internal/scoring"
+)
+
+func estimate(t *testing.T, opts UncertaintyOptions) UncertaintyResult {
+	t.Helper()
+	loc := data.DatacenterLocation{Latitude: 39.0438, Longitude: -77.4874}
+	result, err := EstimateUncertainty(&loc, nil, data.StandardFacility(), scoring.DefaultProfile(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func TestEstimateUncertaintySeed(t *testing.T) {
+	seed := int64(42)
+	first := estimate(t, UncertaintyOptions{Samples: 500, Seed: &seed})
+	second := estimate(t, UncertaintyOptions{Samples: 500, Seed: &seed})
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed gave different results:\n%+v\n%+v", first, second)
+	}
+	other := int64(43)
+	if third := estimate(t, UncertaintyOptions{Samples: 500, Seed: &other}); reflect.DeepEqual(first.CarbonImpact, third.CarbonImpact) {
+		t.Error("different seeds gave the same carbon distribution")
+	}
+}
+
+func TestEstimateUncertaintyPercentiles(t *testing.T) {
+	seed := int64(7)
+	result := estimate(t, UncertaintyOptions{Samples: 1000, Seed: &seed})
+	for name, d := range map[string]Distribution{
+		"eco_score": result.EcoScore, "carbon_impact": result.CarbonImpact,
+		"water_usage": result.WaterUsage, "temp_increase": result.TempIncrease,
+	} {
+		if !(d.P5 <= d.P50 && d.P50 <= d.P95) || d.Mean < d.P5 || d.Mean > d.P95 {
+			t.Errorf("%s: %+v", name, d)
+		}
+	}
+	if result.CarbonImpact.P5 == result.CarbonImpact.P95 {
+		t.Error("carbon impact did not vary")
+	}
+
+	// Holding every input fixed collapses each distribution to a point.
+	zero := float64Ptr(0)
+	fixed := estimate(t, UncertaintyOptions{Samples: 50, Seed: &seed,
+		GridIntensitySpread: zero, WaterStressSpread: zero, PUESpread: zero, WUESpread: zero})
+	if d := fixed.CarbonImpact; d.P5 != d.P95 || math.Abs(d.Mean-d.P50) > 1e-9*d.P50 {
+		t.Errorf("fixed inputs: %+v", d)
+	}
+}
+
+func TestEstimateUncertaintyRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		opts UncertaintyOptions
+	}{
+		{"negative samples", UncertaintyOptions{Samples: -1}},
+		{"too many samples", UncertaintyOptions{Samples: maxUncertaintySamples + 1}},
+		{"negative spread", UncertaintyOptions{PUESpread: float64Ptr(-0.1)}},
+		{"huge grid spread", UncertaintyOptions{GridIntensitySpread: float64Ptr(1000)}},
+		{"huge water spread", UncertaintyOptions{WaterStressSpread: float64Ptr(6)}},
+		{"NaN spread", UncertaintyOptions{WUESpread: float64Ptr(math.NaN())}},
+		{"infinite spread", UncertaintyOptions{GridIntensitySpread: float64Ptr(math.Inf(1))}},
+	}
+	loc := data.DatacenterLocation{Latitude: 39.0438, Longitude: -77.4874}
+	for _, tt := range tests {
+		if _, err := EstimateUncertainty(&loc, nil, data.StandardFacility(), scoring.DefaultProfile(), tt.opts); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	body := `{"latitude":39,"longitude":-77.5,"uncertainty":{"grid_intensity_spread":1000}}`
+	ScoreCoordinateHandler(rec, httptest.NewRequest(http.MethodPost, "/api/score", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("score with a huge spread: status %d, want 400", rec.Code)
+	}
+}
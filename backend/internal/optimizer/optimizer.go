@@ -0,0 +1,321 @@
+// Package optimizer searches for site portfolios that trade off carbon, water and
+// capacity within a budget.
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Candidate is a site that can be added to a portfolio.
+type Candidate struct {
+	Cost     float64 // dollars
+	Carbon   float64 // metric tons CO2e/year
+	Water    float64 // gallons/year
+	Capacity float64 // MW of IT load
+}
+
+// Portfolio is a set of candidates, by index, and their totals.
+type Portfolio struct {
+	Sites    []int
+	Cost     float64
+	Carbon   float64
+	Water    float64
+	Capacity float64
+}
+
+// Options controls the NSGA-II search.
+type Options struct {
+	Budget      float64
+	MaxSites    int // 0 means no limit besides the budget
+	Population  int
+	Generations int
+	Seed        int64
+}
+
+const (
+	defaultPopulation  = 100
+	defaultGenerations = 200
+)
+
+// Each generation sorts the population, quadratic in its size, and builds one child per
+// individual, linear in the number of candidates. The search is capped on both, at
+// MaxSearchWork for population² × generations and MaxEvaluations for population ×
+// generations, which keeps a search over the possible locations to about a second.
+const (
+	MaxPopulation  = 1000
+	MaxGenerations = 2000
+	MaxSearchWork  = 10_000_000
+	MaxEvaluations = 30_000
+)
+
+// individual is a portfolio in the population with its NSGA-II bookkeeping.
+type individual struct {
+	chosen   []bool
+	p        Portfolio
+	rank     int
+	crowding float64
+}
+
+// Validate fills in the default population and generations and checks the options
+// against the search limits.
+func (o *Options) Validate() error {
+	if !(o.Budget > 0) || math.IsInf(o.Budget, 0) {
+		return fmt.Errorf("budget must be a positive finite amount")
+	}
+	if o.MaxSites < 0 {
+		return fmt.Errorf("max sites must not be negative")
+	}
+	if o.Population == 0 {
+		o.Population = defaultPopulation
+	}
+	if o.Generations == 0 {
+		o.Generations = defaultGenerations
+	}
+	if o.Population < 4 || o.Generations < 1 {
+		return fmt.Errorf("population must be at least 4 and generations at least 1")
+	}
+	if o.Population > MaxPopulation || o.Generations > MaxGenerations {
+		return fmt.Errorf("population must be at most %d and generations at most %d", MaxPopulation, MaxGenerations)
+	}
+	if o.Population*o.Population*o.Generations > MaxSearchWork || o.Population*o.Generations > MaxEvaluations {
+		return fmt.Errorf("population² × generations must be at most %d and population × generations at most %d", MaxSearchWork, MaxEvaluations)
+	}
+	return nil
+}
+
+// ParetoFront runs NSGA-II over which candidates to build, minimising carbon and water
+// and maximising capacity while keeping the total cost within the budget. It returns the
+// distinct non-empty non-dominated portfolios, by capacity then carbon. The search stops
+// with ctx's error when ctx is done.
+func ParetoFront(ctx context.Context, candidates []Candidate, opts Options) ([]Portfolio, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &search{
+		candidates:   candidates,
+		opts:         opts,
+		rng:          rand.New(rand.NewSource(opts.Seed)),
+		tooExpensive: make([]bool, len(candidates)),
+	}
+	cheapest := math.Inf(1)
+	for i, c := range candidates {
+		s.tooExpensive[i] = c.Cost > opts.Budget
+		cheapest = math.Min(cheapest, c.Cost)
+	}
+	s.maxFit = len(candidates)
+	if cheapest > 0 && opts.Budget/cheapest < float64(s.maxFit) {
+		s.maxFit = int(opts.Budget / cheapest)
+	}
+	if opts.MaxSites > 0 && opts.MaxSites < s.maxFit {
+		s.maxFit = opts.MaxSites
+	}
+
+	pop := make([]*individual, opts.Population)
+	for i := range pop {
+		pop[i] = s.random()
+	}
+	s.sortPopulation(pop)
+
+	for g := 0; g < opts.Generations; g++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		offspring := make([]*individual, 0, len(pop))
+		for len(offspring) < len(pop) {
+			a, b := s.tournament(pop), s.tournament(pop)
+			offspring = append(offspring, s.child(a, b))
+		}
+		combined := append(append([]*individual{}, pop...), offspring...)
+		s.sortPopulation(combined)
+		pop = combined[:opts.Population]
+	}
+
+	var front []Portfolio
+	seen := make(map[string]bool)
+	for _, ind := range pop {
+		if ind.rank != 0 || len(ind.p.Sites) == 0 {
+			continue
+		}
+		key := fmt.Sprint(ind.p.Sites)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		front = append(front, ind.p)
+	}
+	sort.Slice(front, func(i, j int) bool {
+		if front[i].Capacity != front[j].Capacity {
+			return front[i].Capacity > front[j].Capacity
+		}
+		return front[i].Carbon < front[j].Carbon
+	})
+	return front, nil
+}
+
+type search struct {
+	candidates []Candidate
+	opts       Options
+	rng        *rand.Rand
+	// tooExpensive marks candidates that cost more than the whole budget.
+	tooExpensive []bool
+	// maxFit bounds how many sites any portfolio can hold.
+	maxFit int
+}
+
+func (s *search) random() *individual {
+	chosen := make([]bool, len(s.candidates))
+	// Start from a random fill level so the first population spans the capacity range.
+	target := s.rng.Intn(s.maxFit + 1)
+	for _, i := range s.rng.Perm(len(s.candidates))[:target] {
+		chosen[i] = true
+	}
+	return s.evaluate(chosen)
+}
+
+// child crosses two parents uniformly, mutates the result and repairs it to fit the budget.
+func (s *search) child(a, b *individual) *individual {
+	chosen := make([]bool, len(s.candidates))
+	for i := range chosen {
+		if s.rng.Intn(2) == 0 {
+			chosen[i] = a.chosen[i]
+		} else {
+			chosen[i] = b.chosen[i]
+		}
+	}
+	if len(chosen) > 0 {
+		switch r := s.rng.Float64(); {
+		case r < 0.4:
+			chosen[s.rng.Intn(len(chosen))] = true
+		case r < 0.7:
+			chosen[s.rng.Intn(len(chosen))] = false
+		default:
+			i, j := s.rng.Intn(len(chosen)), s.rng.Intn(len(chosen))
+			chosen[i], chosen[j] = chosen[j], chosen[i]
+		}
+	}
+	return s.evaluate(chosen)
+}
+
+// evaluate drops random sites until the portfolio is within budget and the site limit,
+// then totals it.
+func (s *search) evaluate(chosen []bool) *individual {
+	var picked []int
+	cost := 0.0
+	for i, c := range chosen {
+		if c && s.tooExpensive[i] {
+			chosen[i] = false
+		} else if c {
+			picked = append(picked, i)
+			cost += s.candidates[i].Cost
+		}
+	}
+	for len(picked) > 0 && (cost > s.opts.Budget || (s.opts.MaxSites > 0 && len(picked) > s.opts.MaxSites)) {
+		k := s.rng.Intn(len(picked))
+		i := picked[k]
+		chosen[i] = false
+		cost -= s.candidates[i].Cost
+		picked[k] = picked[len(picked)-1]
+		picked = picked[:len(picked)-1]
+	}
+
+	sort.Ints(picked)
+	p := Portfolio{Sites: picked}
+	for _, i := range picked {
+		c := s.candidates[i]
+		p.Cost += c.Cost
+		p.Carbon += c.Carbon
+		p.Water += c.Water
+		p.Capacity += c.Capacity
+	}
+	return &individual{chosen: chosen, p: p}
+}
+
+// dominates reports whether a is no worse than b on every objective and better on one.
+func dominates(a, b Portfolio) bool {
+	if a.Carbon > b.Carbon || a.Water > b.Water || a.Capacity < b.Capacity {
+		return false
+	}
+	return a.Carbon < b.Carbon || a.Water < b.Water || a.Capacity > b.Capacity
+}
+
+// sortPopulation assigns non-dominated ranks and crowding distances, then orders the
+// population best first.
+func (s *search) sortPopulation(pop []*individual) {
+	dominatedBy := make([][]int, len(pop))
+	count := make([]int, len(pop))
+	var front []int
+	for i := range pop {
+		for j := range pop {
+			if i == j {
+				continue
+			}
+			if dominates(pop[i].p, pop[j].p) {
+				dominatedBy[i] = append(dominatedBy[i], j)
+			} else if dominates(pop[j].p, pop[i].p) {
+				count[i]++
+			}
+		}
+		if count[i] == 0 {
+			front = append(front, i)
+		}
+	}
+	for rank := 0; len(front) > 0; rank++ {
+		members := make([]*individual, len(front))
+		var next []int
+		for k, i := range front {
+			pop[i].rank = rank
+			members[k] = pop[i]
+			for _, j := range dominatedBy[i] {
+				count[j]--
+				if count[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		crowding(members)
+		front = next
+	}
+
+	sort.SliceStable(pop, func(i, j int) bool {
+		if pop[i].rank != pop[j].rank {
+			return pop[i].rank < pop[j].rank
+		}
+		return pop[i].crowding > pop[j].crowding
+	})
+}
+
+func crowding(front []*individual) {
+	for _, ind := range front {
+		ind.crowding = 0
+	}
+	for _, objective := range []func(Portfolio) float64{
+		func(p Portfolio) float64 { return p.Carbon },
+		func(p Portfolio) float64 { return p.Water },
+		func(p Portfolio) float64 { return p.Capacity },
+	} {
+		sort.SliceStable(front, func(i, j int) bool { return objective(front[i].p) < objective(front[j].p) })
+		lo, hi := objective(front[0].p), objective(front[len(front)-1].p)
+		front[0].crowding = math.Inf(1)
+		front[len(front)-1].crowding = math.Inf(1)
+		if hi == lo {
+			continue
+		}
+		for k := 1; k < len(front)-1; k++ {
+			front[k].crowding += (objective(front[k+1].p) - objective(front[k-1].p)) / (hi - lo)
+		}
+	}
+}
+
+// tournament picks the better of two random individuals.
+func (s *search) tournament(pop []*individual) *individual {
+	a, b := pop[s.rng.Intn(len(pop))], pop[s.rng.Intn(len(pop))]
+	if a.rank < b.rank || (a.rank == b.rank && a.crowding > b.crowding) {
+		return a
+	}
+	return b
+}
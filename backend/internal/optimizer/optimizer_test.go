@@ -0,0 +1,149 @@
+package optimizer
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func testCandidates(n int, seed int64) []Candidate {
+	rng := rand.New(rand.NewSource(seed))
+	candidates := make([]Candidate, n)
+	for i := range candidates {
+		candidates[i] = Candidate{
+			Cost:     1 + rng.Float64()*9,
+			Carbon:   100 + rng.Float64()*900,
+			Water:    1000 + rng.Float64()*9000,
+			Capacity: 15,
+		}
+	}
+	return candidates
+}
+
+// bruteForceFront enumerates every non-empty portfolio within the budget and keeps the
+// non-dominated ones.
+func bruteForceFront(candidates []Candidate, budget float64) []Portfolio {
+	var all []Portfolio
+	for mask := 1; mask < 1<<len(candidates); mask++ {
+		var p Portfolio
+		for i, c := range candidates {
+			if mask&(1<<i) != 0 {
+				p.Sites = append(p.Sites, i)
+				p.Cost += c.Cost
+				p.Carbon += c.Carbon
+				p.Water += c.Water
+				p.Capacity += c.Capacity
+			}
+		}
+		if p.Cost <= budget {
+			all = append(all, p)
+		}
+	}
+	var front []Portfolio
+	for _, p := range all {
+		dominated := false
+		for _, q := range all {
+			if dominates(q, p) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, p)
+		}
+	}
+	return front
+}
+
+func TestParetoFront(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		budget   float64
+		maxSites int
+	}{
+		{"tight budget", 8, 6, 0},
+		{"loose budget", 8, 30, 0},
+		{"site limit", 8, 100, 2},
+		{"everything fits", 6, 1000, 0},
+	}
+	for _, tt := range tests {
+		candidates := testCandidates(tt.n, 1)
+		front, err := ParetoFront(context.Background(), candidates, Options{Budget: tt.budget, MaxSites: tt.maxSites, Seed: 1})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(front) == 0 {
+			t.Errorf("%s: empty front", tt.name)
+		}
+		for _, p := range front {
+			if p.Cost > tt.budget+1e-9 {
+				t.Errorf("%s: portfolio %v costs %.2f over the budget", tt.name, p.Sites, p.Cost)
+			}
+			if tt.maxSites > 0 && len(p.Sites) > tt.maxSites {
+				t.Errorf("%s: portfolio %v has more than %d sites", tt.name, p.Sites, tt.maxSites)
+			}
+		}
+		if tt.maxSites > 0 {
+			continue
+		}
+		// Every portfolio found must be on the true front.
+		for _, p := range front {
+			for _, q := range bruteForceFront(candidates, tt.budget) {
+				if dominates(q, p) {
+					t.Errorf("%s: portfolio %v is dominated by %v", tt.name, p.Sites, q.Sites)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestParetoFrontRejectsOptions(t *testing.T) {
+	candidates := testCandidates(10, 1)
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"zero budget", Options{}},
+		{"NaN budget", Options{Budget: math.NaN()}},
+		{"infinite budget", Options{Budget: math.Inf(1)}},
+		{"negative max sites", Options{Budget: 10, MaxSites: -1}},
+		{"small population", Options{Budget: 10, Population: 2}},
+		{"population over the cap", Options{Budget: 10, Population: MaxPopulation + 1, Generations: 1}},
+		{"generations over the cap", Options{Budget: 10, Population: 4, Generations: MaxGenerations + 1}},
+		{"too much sorting", Options{Budget: 10, Population: 1000, Generations: 20}},
+		{"too many evaluations", Options{Budget: 10, Population: 100, Generations: 1000}},
+	}
+	for _, tt := range tests {
+		if _, err := ParetoFront(context.Background(), candidates, tt.opts); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestParetoFrontStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParetoFront(ctx, testCandidates(50, 1), Options{Budget: 100}); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestParetoFrontMaxWorkIsBounded(t *testing.T) {
+	if testing.Short() {
+		t.Skip("slow")
+	}
+	candidates := testCandidates(646, 1)
+	for _, size := range [][2]int{{1000, MaxSearchWork / (1000 * 1000)}, {150, MaxEvaluations / 150}, {15, MaxGenerations}} {
+		start := time.Now()
+		if _, err := ParetoFront(context.Background(), candidates, Options{Budget: 200, Population: size[0], Generations: size[1], Seed: 1}); err != nil {
+			t.Fatalf("population %d, generations %d: %v", size[0], size[1], err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("population %d, generations %d took %v", size[0], size[1], elapsed)
+		}
+	}
+}
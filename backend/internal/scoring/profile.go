@@ -0,0 +1,92 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// DefaultProfileName is the built-in research-based profile.
+const DefaultProfileName = "default"
+
+// Weights are the shares of each normalised impact in the eco score. They must sum to 1.
+type Weights struct {
+	Carbon float64 `json:"carbon"`
+	Water  float64 `json:"water"`
+	Temp   float64 `json:"temp"`
+	Land   float64 `json:"land"`
+	Social float64 `json:"social"`
+}
+
+// Normalisers are the impacts that count as a full unit in the eco score.
+// The social impact is already on a 0-1 scale.
+type Normalisers struct {
+	Carbon float64 `json:"carbon"` // kg CO2e/year
+	Water  float64 `json:"water"`  // scarcity-weighted litres/year
+	Temp   float64 `json:"temp"`   // °C
+	Land   float64 `json:"land"`   // sensitivity-weighted hectares
+}
+
+// Profile is a named set of eco score weights and normalisers. Profiles without an
+// owner are global and visible to every user.
+type Profile struct {
+	Name        string      `json:"name"`
+	Owner       string      `json:"owner,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Weights     Weights     `json:"weights"`
+	Normalisers Normalisers `json:"normalisers"`
+	BuiltIn     bool        `json:"built_in,omitempty"`
+}
+
+// DefaultProfile returns the research-based weights used when no profile is selected.
+func DefaultProfile() Profile {
+	return Profile{
+		Name:        DefaultProfileName,
+		Description: "Research-based weights",
+		Weights: Weights{
+			Carbon: 0.40,
+			Water:  0.25,
+			Temp:   0.20,
+			Land:   0.10,
+			Social: 0.05,
+		},
+		Normalisers: Normalisers{
+			Carbon: 5000000.0,
+			Water:  50000000.0,
+			Temp:   2.0,
+			Land:   10.0,
+		},
+		BuiltIn: true,
+	}
+}
+
+// weightTolerance is how far the weights may sum from 1.
+const weightTolerance = 1e-6
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Validate checks the name, that every weight is non-negative and they sum to 1,
+// and that every normaliser is positive.
+func (p Profile) Validate() error {
+	if !validName.MatchString(p.Name) {
+		return fmt.Errorf("invalid profile name %q: use up to 64 letters, digits, '.', '_' or '-'", p.Name)
+	}
+	w := p.Weights
+	sum := 0.0
+	for name, v := range map[string]float64{"carbon": w.Carbon, "water": w.Water, "temp": w.Temp, "land": w.Land, "social": w.Social} {
+		if v < 0 || math.IsNaN(v) {
+			return fmt.Errorf("weight %s must not be negative", name)
+		}
+		sum += v
+	}
+	if math.Abs(sum-1) > weightTolerance {
+		return fmt.Errorf("weights must sum to 1, got %g", sum)
+	}
+	n := p.Normalisers
+	for name, v := range map[string]float64{"carbon": n.Carbon, "water": n.Water, "temp": n.Temp, "land": n.Land} {
+		if !(v > 0) {
+			return fmt.Errorf("normaliser %s must be positive", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package scoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// profiles maps owner ("" for global) to profile name to Profile.
+	profiles   = make(map[string]map[string]Profile)
+	profileMu  sync.RWMutex
+	profileDir = "./profiles" // global.json plus users/<username>.json
+)
+
+func checkOwner(owner string) error {
+	if strings.ContainsAny(owner, `/\`) || owner == "." || owner == ".." {
+		return fmt.Errorf("invalid username %q", owner)
+	}
+	return nil
+}
+
+func profilePath(owner string) string {
+	if owner == "" {
+		return filepath.Join(profileDir, "global.json")
+	}
+	return filepath.Join(profileDir, "users", owner+".json")
+}
+
+// LoadAllProfiles loads the global and per-user profile files when the app starts.
+func LoadAllProfiles() error {
+	if err := os.MkdirAll(filepath.Join(profileDir, "users"), 0755); err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(profileDir, "users", "*.json"))
+	if err != nil {
+		return err
+	}
+	owners := []string{""}
+	for _, f := range files {
+		owners = append(owners, strings.TrimSuffix(filepath.Base(f), ".json"))
+	}
+
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	for _, owner := range owners {
+		content, err := os.ReadFile(profilePath(owner))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		var list []Profile
+		if err := json.Unmarshal(content, &list); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", profilePath(owner), err)
+		}
+		for _, p := range list {
+			p.Owner = owner
+			p.BuiltIn = false
+			if err := p.Validate(); err != nil {
+				return fmt.Errorf("%s: %w", profilePath(owner), err)
+			}
+			if profiles[owner] == nil {
+				profiles[owner] = make(map[string]Profile)
+			}
+			profiles[owner][p.Name] = p
+		}
+	}
+	return nil
+}
+
+// saveOwnerNoLock writes every profile of owner, assuming the lock is already held.
+func saveOwnerNoLock(owner string) error {
+	path := profilePath(owner)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	list := make([]Profile, 0, len(profiles[owner]))
+	for _, p := range profiles[owner] {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	content, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// SaveProfile validates and stores a profile for p.Owner, or globally when the owner is
+// empty, replacing any profile of the same name. Omitted normalisers use the defaults.
+func SaveProfile(p Profile) (Profile, error) {
+	if p.Normalisers == (Normalisers{}) {
+		p.Normalisers = DefaultProfile().Normalisers
+	}
+	p.BuiltIn = false
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+	if p.Name == DefaultProfileName {
+		return p, fmt.Errorf("profile %q is built in", DefaultProfileName)
+	}
+	if err := checkOwner(p.Owner); err != nil {
+		return p, err
+	}
+
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	if profiles[p.Owner] == nil {
+		profiles[p.Owner] = make(map[string]Profile)
+	}
+	profiles[p.Owner][p.Name] = p
+	return p, saveOwnerNoLock(p.Owner)
+}
+
+// GetProfile finds a profile by name for a user, preferring the user's own profile over
+// a global one. An empty name returns the default profile.
+func GetProfile(username, name string) (Profile, bool) {
+	if name == "" || name == DefaultProfileName {
+		return DefaultProfile(), true
+	}
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	if username != "" {
+		if p, ok := profiles[username][name]; ok {
+			return p, true
+		}
+	}
+	p, ok := profiles[""][name]
+	return p, ok
+}
+
+// ListProfiles returns the default profile, the global profiles and the user's own
+// profiles, in that order and sorted by name within each group.
+func ListProfiles(username string) []Profile {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	list := []Profile{DefaultProfile()}
+	owners := []string{""}
+	if username != "" {
+		owners = append(owners, username)
+	}
+	for _, owner := range owners {
+		var group []Profile
+		for _, p := range profiles[owner] {
+			group = append(group, p)
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Name < group[j].Name })
+		list = append(list, group...)
+	}
+	return list
+}
+
+// DeleteProfile removes a user's profile, or a global one when owner is empty.
+func DeleteProfile(owner, name string) error {
+	if err := checkOwner(owner); err != nil {
+		return err
+	}
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	if _, ok := profiles[owner][name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	delete(profiles[owner], name)
+	return saveOwnerNoLock(owner)
+}
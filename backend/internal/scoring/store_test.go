@@ -0,0 +1,101 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+// useTempStore points the profile store at an empty temporary directory.
+func useTempStore(t *testing.T) {
+	t.Helper()
+	savedDir, savedProfiles := profileDir, profiles
+	profileDir, profiles = t.TempDir(), make(map[string]map[string]Profile)
+	t.Cleanup(func() { profileDir, profiles = savedDir, savedProfiles })
+}
+
+func testProfile(name string, carbon float64) Profile {
+	return Profile{Name: name, Weights: Weights{Carbon: carbon, Water: 1 - carbon}}
+}
+
+func TestProfileStoreRoundTrip(t *testing.T) {
+	useTempStore(t)
+	if _, err := SaveProfile(testProfile("water-first", 0.2)); err != nil {
+		t.Fatal(err)
+	}
+	mine := testProfile("water-first", 0.1)
+	mine.Owner = "alice"
+	saved, err := SaveProfile(mine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Normalisers != DefaultProfile().Normalisers {
+		t.Errorf("omitted normalisers saved as %+v", saved.Normalisers)
+	}
+
+	// Reload from disk.
+	profiles = make(map[string]map[string]Profile)
+	if err := LoadAllProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := GetProfile("alice", "water-first"); !ok || p.Owner != "alice" || p.Weights.Carbon != 0.1 {
+		t.Errorf("alice's profile = %+v, %v", p, ok)
+	}
+	if p, ok := GetProfile("bob", "water-first"); !ok || p.Owner != "" || p.Weights.Carbon != 0.2 {
+		t.Errorf("global profile for bob = %+v, %v", p, ok)
+	}
+	if p, ok := GetProfile("bob", ""); !ok || p.Name != DefaultProfileName {
+		t.Errorf("empty name = %+v, %v", p, ok)
+	}
+	if list := ListProfiles("alice"); len(list) != 3 || list[0].Name != DefaultProfileName || list[1].Owner != "" || list[2].Owner != "alice" {
+		t.Errorf("ListProfiles(alice) = %+v", list)
+	}
+	if list := ListProfiles(""); len(list) != 2 {
+		t.Errorf("ListProfiles() has %d profiles, want 2", len(list))
+	}
+
+	if err := DeleteProfile("alice", "water-first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteProfile("alice", "water-first"); err == nil {
+		t.Error("deleted a missing profile")
+	}
+	profiles = make(map[string]map[string]Profile)
+	if err := LoadAllProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := GetProfile("alice", "water-first"); p.Owner != "" {
+		t.Error("deleted profile came back after a reload")
+	}
+}
+
+func TestSaveProfileRejects(t *testing.T) {
+	useTempStore(t)
+	negative := testProfile("negative", 1.2)
+	nan := testProfile("nan", 0.5)
+	nan.Weights.Temp = math.NaN()
+	badNormaliser := testProfile("bad-normaliser", 0.5)
+	badNormaliser.Normalisers = DefaultProfile().Normalisers
+	badNormaliser.Normalisers.Temp = 0
+	badOwner := testProfile("owned", 0.5)
+	badOwner.Owner = "../etc"
+	tests := []struct {
+		name string
+		p    Profile
+	}{
+		{"negative weight", negative},
+		{"NaN weight", nan},
+		{"weights not summing to 1", Profile{Name: "short", Weights: Weights{Carbon: 0.5}}},
+		{"invalid name", testProfile("../escape", 0.5)},
+		{"built-in name", testProfile(DefaultProfileName, 0.5)},
+		{"zero normaliser", badNormaliser},
+		{"invalid owner", badOwner},
+	}
+	for _, tt := range tests {
+		if _, err := SaveProfile(tt.p); err == nil {
+			t.Errorf("%s: saved", tt.name)
+		}
+	}
+	if list := ListProfiles(""); len(list) != 1 {
+		t.Errorf("rejected profiles were stored: %+v", list)
+	}
+}
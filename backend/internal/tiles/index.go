@@ -0,0 +1,145 @@
+package tiles
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// MaxZoom is the deepest zoom level served.
+	MaxZoom = 22
+
+	// ClusterMaxZoom is the last zoom level at which nearby points are clustered.
+	ClusterMaxZoom = 12
+
+	// clusterCell is the edge of a clustering cell in tile units. It divides Extent, so
+	// cells never straddle tiles and a cluster is the same in every tile that shows it.
+	clusterCell = Extent / 8
+
+	// buffer is how far outside the tile, in tile units, unclustered points are still
+	// included so markers on the edge are not cut off.
+	buffer = 64
+
+	maxLatitude = 85.05112878 // edge of the Web Mercator square
+)
+
+// Point is a location to draw, with the properties of its feature.
+type Point struct {
+	Lat, Lng   float64
+	Properties map[string]interface{}
+}
+
+type indexedPoint struct {
+	x, y float64 // Web Mercator, 0..1 from the top-left of the world
+	id   uint64
+}
+
+// Index holds points sorted by Web Mercator x so a tile only visits the points in its
+// column. It is read-only once built and safe to share.
+type Index struct {
+	points []Point
+	sorted []indexedPoint
+}
+
+// NewIndex builds an index over points. Feature ids are positions in points, plus one.
+func NewIndex(points []Point) *Index {
+	idx := &Index{points: points, sorted: make([]indexedPoint, len(points))}
+	for i, p := range points {
+		x, y := project(p.Lat, p.Lng)
+		idx.sorted[i] = indexedPoint{x: x, y: y, id: uint64(i + 1)}
+	}
+	sort.Slice(idx.sorted, func(i, j int) bool { return idx.sorted[i].x < idx.sorted[j].x })
+	return idx
+}
+
+// Len returns the number of indexed points.
+func (idx *Index) Len() int {
+	return len(idx.points)
+}
+
+// project converts a coordinate to Web Mercator, scaled to 0..1.
+func project(lat, lng float64) (x, y float64) {
+	lat = math.Max(-maxLatitude, math.Min(maxLatitude, lat))
+	x = (lng + 180) / 360
+	sin := math.Sin(lat * math.Pi / 180)
+	y = 0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)
+	return x, y
+}
+
+// Tile returns the features of tile z/x/y. Up to ClusterMaxZoom, points sharing a
+// clustering cell are merged into one feature at their centroid, with the properties
+// cluster=true and point_count; a cell holding one point keeps that point's feature.
+func (idx *Index) Tile(z, x, y int) []Feature {
+	scale := float64(int(1)<<z) * Extent // world size in tile units
+	originX, originY := float64(x)*Extent, float64(y)*Extent
+
+	pad := float64(buffer)
+	cluster := z <= ClusterMaxZoom
+	if cluster {
+		pad = 0
+	}
+	minX, maxX := (originX-pad)/scale, (originX+Extent+pad)/scale
+	minY, maxY := (originY-pad)/scale, (originY+Extent+pad)/scale
+
+	type cell struct {
+		sumX, sumY float64
+		count      int
+		first      indexedPoint
+	}
+	var (
+		features []Feature
+		cells    = make(map[[2]int]*cell)
+		order    [][2]int
+	)
+	start := sort.Search(len(idx.sorted), func(i int) bool { return idx.sorted[i].x >= minX })
+	for _, p := range idx.sorted[start:] {
+		if p.x >= maxX {
+			break
+		}
+		if p.y < minY || p.y >= maxY {
+			continue
+		}
+		px, py := p.x*scale-originX, p.y*scale-originY
+		if !cluster {
+			features = append(features, idx.feature(p, px, py))
+			continue
+		}
+		key := [2]int{int(px) / clusterCell, int(py) / clusterCell}
+		c, ok := cells[key]
+		if !ok {
+			c = &cell{first: p}
+			cells[key] = c
+			order = append(order, key)
+		}
+		c.sumX += px
+		c.sumY += py
+		c.count++
+	}
+
+	for _, key := range order {
+		c := cells[key]
+		cx, cy := c.sumX/float64(c.count), c.sumY/float64(c.count)
+		if c.count == 1 {
+			features = append(features, idx.feature(c.first, cx, cy))
+			continue
+		}
+		features = append(features, Feature{
+			X: int(math.Round(cx)),
+			Y: int(math.Round(cy)),
+			Properties: map[string]interface{}{
+				"cluster":     true,
+				"point_count": c.count,
+			},
+		})
+	}
+	return features
+}
+
+func (idx *Index) feature(p indexedPoint, px, py float64) Feature {
+	return Feature{
+		ID:         p.id,
+		X:          int(math.Round(px)),
+		Y:          int(math.Round(py)),
+		Properties: idx.points[p.id-1].Properties,
+	}
+}
@@ -0,0 +1,190 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ContentType is the media type of an encoded tile.
+const ContentType = "application/vnd.mapbox-vector-tile"
+
+// Extent is the size of a tile in its own coordinate space.
+const Extent = 4096
+
+// Layer is one named layer of a vector tile.
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+// Feature is a point in tile coordinates, 0..Extent from the top-left corner. Points in
+// the buffer around the tile fall outside that range. Property values may be strings,
+// booleans, integers or floats; anything else is written as a string.
+type Feature struct {
+	ID         uint64 // 0 leaves the id unset
+	X, Y       int
+	Properties map[string]interface{}
+}
+
+// Protocol buffer field numbers of the Mapbox Vector Tile 2.1 spec.
+const (
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueInt    = 4
+	valueBool   = 7
+
+	geomTypePoint = 1
+	cmdMoveTo     = 1
+)
+
+// Encode writes the layers as a Mapbox Vector Tile. Layers without features are left out.
+func Encode(layers ...Layer) []byte {
+	var tile pbuf
+	for _, l := range layers {
+		if len(l.Features) > 0 {
+			tile.bytes(tileLayers, encodeLayer(l))
+		}
+	}
+	return tile
+}
+
+func encodeLayer(l Layer) []byte {
+	var (
+		buf      pbuf
+		keys     []string
+		keyIndex = make(map[string]uint32)
+		values   [][]byte
+		valIndex = make(map[string]uint32)
+	)
+	buf.varint(layerVersion, 2)
+	buf.bytes(layerName, []byte(l.Name))
+
+	for _, f := range l.Features {
+		names := make([]string, 0, len(f.Properties))
+		for k := range f.Properties {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		var tags []uint32
+		for _, k := range names {
+			v := encodeValue(f.Properties[k])
+			if v == nil {
+				continue
+			}
+			ki, ok := keyIndex[k]
+			if !ok {
+				ki = uint32(len(keys))
+				keyIndex[k] = ki
+				keys = append(keys, k)
+			}
+			vi, ok := valIndex[string(v)]
+			if !ok {
+				vi = uint32(len(values))
+				valIndex[string(v)] = vi
+				values = append(values, v)
+			}
+			tags = append(tags, ki, vi)
+		}
+
+		var feature pbuf
+		if f.ID != 0 {
+			feature.varint(featureID, f.ID)
+		}
+		if len(tags) > 0 {
+			feature.packed(featureTags, tags)
+		}
+		feature.varint(featureType, geomTypePoint)
+		feature.packed(featureGeometry, []uint32{cmdMoveTo | 1<<3, zigzag(f.X), zigzag(f.Y)})
+		buf.bytes(layerFeatures, feature)
+	}
+
+	for _, k := range keys {
+		buf.bytes(layerKeys, []byte(k))
+	}
+	for _, v := range values {
+		buf.bytes(layerValues, v)
+	}
+	buf.varint(layerExtent, Extent)
+	return buf
+}
+
+// encodeValue returns the Value message for v, or nil for nil.
+func encodeValue(v interface{}) []byte {
+	var buf pbuf
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		buf.bytes(valueString, []byte(v))
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		buf.varint(valueBool, b)
+	case int:
+		buf.varint(valueInt, uint64(v))
+	case int64:
+		buf.varint(valueInt, uint64(v))
+	case float64:
+		buf.double(valueDouble, v)
+	case float32:
+		buf.double(valueDouble, float64(v))
+	default:
+		buf.bytes(valueString, []byte(fmt.Sprint(v)))
+	}
+	return buf
+}
+
+func zigzag(n int) uint32 {
+	v := int32(n)
+	return uint32((v << 1) ^ (v >> 31))
+}
+
+// pbuf appends protocol buffer fields.
+type pbuf []byte
+
+func (b *pbuf) key(field, wireType int) {
+	*b = binary.AppendUvarint(*b, uint64(field<<3|wireType))
+}
+
+func (b *pbuf) varint(field int, v uint64) {
+	b.key(field, 0)
+	*b = binary.AppendUvarint(*b, v)
+}
+
+func (b *pbuf) double(field int, v float64) {
+	b.key(field, 1)
+	*b = binary.LittleEndian.AppendUint64(*b, math.Float64bits(v))
+}
+
+func (b *pbuf) bytes(field int, v []byte) {
+	b.key(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *pbuf) packed(field int, vs []uint32) {
+	var inner []byte
+	for _, v := range vs {
+		inner = binary.AppendUvarint(inner, uint64(v))
+	}
+	b.bytes(field, inner)
+}
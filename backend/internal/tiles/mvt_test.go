@@ -0,0 +1,195 @@
+package tiles
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// pbField is one decoded protocol buffer field; v holds varint and fixed64 values.
+type pbField struct {
+	num int
+	v   uint64
+	b   []byte
+}
+
+func decodeFields(t *testing.T, b []byte) []pbField {
+	t.Helper()
+	var fields []pbField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("bad field key in %x", b)
+		}
+		b = b[n:]
+		f := pbField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.v, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("bad varint in %x", b)
+			}
+			b = b[n:]
+		case 1:
+			f.v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				t.Fatalf("bad length in %x", b)
+			}
+			f.b, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func decodePacked(t *testing.T, b []byte) []uint32 {
+	t.Helper()
+	var vs []uint32
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("bad packed varint in %x", b)
+		}
+		vs, b = append(vs, uint32(v)), b[n:]
+	}
+	return vs
+}
+
+func TestZigzag(t *testing.T) {
+	tests := []struct {
+		in   int
+		want uint32
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2, 4}, {-buffer, 2*buffer - 1}, {Extent, 2 * Extent}, {Extent + buffer, 2 * (Extent + buffer)},
+	}
+	for _, tt := range tests {
+		if got := zigzag(tt.in); got != tt.want {
+			t.Errorf("zigzag(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	double := func(f float64) []byte {
+		return binary.LittleEndian.AppendUint64([]byte{valueDouble<<3 | 1}, math.Float64bits(f))
+	}
+	tests := []struct {
+		name string
+		in   interface{}
+		want []byte
+	}{
+		{"nil", nil, nil},
+		{"string", "ab", []byte{valueString<<3 | 2, 2, 'a', 'b'}},
+		{"empty string", "", []byte{valueString<<3 | 2, 0}},
+		{"true", true, []byte{valueBool << 3, 1}},
+		{"false", false, []byte{valueBool << 3, 0}},
+		{"int", 300, []byte{valueInt << 3, 0xac, 0x02}},
+		{"int64", int64(1), []byte{valueInt << 3, 1}},
+		{"negative int", -1, []byte{valueInt << 3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{"float64", 1.5, double(1.5)},
+		{"float32", float32(0.25), double(0.25)},
+		{"other type", uint8(7), []byte{valueString<<3 | 2, 1, '7'}},
+	}
+	for _, tt := range tests {
+		if got := encodeValue(tt.in); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: encodeValue(%v) = %x, want %x", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tile := Encode(
+		Layer{Name: "empty"},
+		Layer{Name: "sites", Features: []Feature{
+			{ID: 1, X: 10, Y: 20, Properties: map[string]interface{}{"name": "A", "score": 50, "skip": nil}},
+			{ID: 2, X: -5, Y: Extent + 5, Properties: map[string]interface{}{"name": "B", "score": 50}},
+			{X: 0, Y: 0},
+		}},
+	)
+
+	layers := decodeFields(t, tile)
+	if len(layers) != 1 || layers[0].num != tileLayers {
+		t.Fatalf("got %d tile fields, want the one non-empty layer", len(layers))
+	}
+
+	var (
+		name            string
+		version, extent uint64
+		keys            []string
+		values          [][]byte
+		features        [][]pbField
+	)
+	for _, f := range decodeFields(t, layers[0].b) {
+		switch f.num {
+		case layerName:
+			name = string(f.b)
+		case layerVersion:
+			version = f.v
+		case layerExtent:
+			extent = f.v
+		case layerKeys:
+			keys = append(keys, string(f.b))
+		case layerValues:
+			values = append(values, f.b)
+		case layerFeatures:
+			features = append(features, decodeFields(t, f.b))
+		}
+	}
+	if name != "sites" || version != 2 || extent != Extent {
+		t.Errorf("layer name %q, version %d, extent %d", name, version, extent)
+	}
+	if want := []string{"name", "score"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+	wantValues := [][]byte{encodeValue("A"), encodeValue(50), encodeValue("B")}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("values = %x, want %x shared between features", values, wantValues)
+	}
+
+	want := []struct {
+		id       uint64
+		tags     []uint32
+		geometry []uint32
+	}{
+		{1, []uint32{0, 0, 1, 1}, []uint32{cmdMoveTo | 1<<3, zigzag(10), zigzag(20)}},
+		{2, []uint32{0, 2, 1, 1}, []uint32{cmdMoveTo | 1<<3, zigzag(-5), zigzag(Extent + 5)}},
+		{0, nil, []uint32{cmdMoveTo | 1<<3, 0, 0}},
+	}
+	if len(features) != len(want) {
+		t.Fatalf("got %d features, want %d", len(features), len(want))
+	}
+	for i, w := range want {
+		var (
+			id             uint64
+			typ            uint64
+			tags, geometry []uint32
+		)
+		for _, f := range features[i] {
+			switch f.num {
+			case featureID:
+				id = f.v
+			case featureType:
+				typ = f.v
+			case featureTags:
+				tags = decodePacked(t, f.b)
+			case featureGeometry:
+				geometry = decodePacked(t, f.b)
+			}
+		}
+		if id != w.id || typ != geomTypePoint || !reflect.DeepEqual(tags, w.tags) || !reflect.DeepEqual(geometry, w.geometry) {
+			t.Errorf("feature %d: id %d, type %d, tags %v, geometry %v; want %d, point, %v, %v", i, id, typ, tags, geometry, w.id, w.tags, w.geometry)
+		}
+	}
+}
+
+func TestEncodeNoFeatures(t *testing.T) {
+	if tile := Encode(Layer{Name: "a"}, Layer{Name: "b", Features: []Feature{}}); len(tile) != 0 {
+		t.Errorf("Encode of empty layers = %x, want an empty tile", tile)
+	}
+}
@@ -0,0 +1,275 @@
+package tiles
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PNGContentType is the media type of a raster tile.
+const PNGContentType = "image/png"
+
+// RasterSize is the edge of a raster tile in pixels.
+const RasterSize = 256
+
+// Ramp is a colour scale from the low to the high end of a domain, with evenly spaced stops.
+type Ramp []color.NRGBA
+
+// Ramps are the named colour ramps; "red-green" is the default.
+var Ramps = map[string]Ramp{
+	"red-green": mustParseStops("#d73027,#fc8d59,#fee08b,#d9ef8b,#91cf60,#1a9850"),
+	"viridis":   mustParseStops("#440154,#3b528b,#21918c,#5ec962,#fde725"),
+	"magma":     mustParseStops("#000004,#51127c,#b73779,#fc8961,#fcfdbf"),
+	"greys":     mustParseStops("#252525,#969696,#f7f7f7"),
+}
+
+// ParseRamp returns a named ramp, or a ramp from two or more comma-separated #rrggbb stops.
+// The empty string is the default ramp.
+func ParseRamp(s string) (Ramp, error) {
+	if s == "" {
+		s = "red-green"
+	}
+	if r, ok := Ramps[strings.ToLower(s)]; ok {
+		return r, nil
+	}
+	return parseStops(s)
+}
+
+func parseStops(s string) (Ramp, error) {
+	var r Ramp
+	for _, stop := range strings.Split(s, ",") {
+		hex := strings.TrimPrefix(strings.TrimSpace(stop), "#")
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return nil, fmt.Errorf("invalid colour %q; use a ramp name or #rrggbb stops", stop)
+		}
+		r = append(r, color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255})
+	}
+	if len(r) < 2 {
+		return nil, fmt.Errorf("a colour ramp needs at least two stops")
+	}
+	return r, nil
+}
+
+func mustParseStops(s string) Ramp {
+	r, err := parseStops(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// At returns the colour at t, clamped to 0..1.
+func (r Ramp) At(t float64) color.NRGBA {
+	t = math.Max(0, math.Min(1, t)) * float64(len(r)-1)
+	i := int(t)
+	if i >= len(r)-1 {
+		return r[len(r)-1]
+	}
+	f := t - float64(i)
+	mix := func(a, b uint8) uint8 { return uint8(math.Round(float64(a) + (float64(b)-float64(a))*f)) }
+	a, b := r[i], r[i+1]
+	return color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+}
+
+// Hex lists the stops as #rrggbb strings.
+func (r Ramp) Hex() []string {
+	stops := make([]string, len(r))
+	for i, c := range r {
+		stops[i] = fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return stops
+}
+
+// unproject converts Web Mercator, scaled to 0..1, back to a coordinate.
+func unproject(x, y float64) (lat, lng float64) {
+	lng = x*360 - 180
+	lat = math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+	return lat, lng
+}
+
+// ValueFunc evaluates the surface at a coordinate. ok is false where it has no value, such
+// as outside the areas with data; those pixels stay transparent.
+type ValueFunc func(lat, lng float64) (v float64, ok bool)
+
+// Heatmap describes how a surface is drawn.
+type Heatmap struct {
+	Min, Max float64 // values mapped to the ends of the ramp
+	Ramp     Ramp
+	Opacity  float64 // 0..1
+	// Step is the spacing of evaluated samples in pixels; pixels between them are
+	// interpolated bilinearly. It must divide RasterSize.
+	Step int
+}
+
+// Render evaluates value on a grid over tile z/x/y and colours it as a PNG.
+func (h Heatmap) Render(z, x, y int, value ValueFunc) ([]byte, error) {
+	n := RasterSize/h.Step + 1
+	scale := float64(int(1)<<z) * RasterSize
+	samples := make([]float64, n*n)
+	valid := make([]bool, n*n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			lat, lng := unproject(
+				(float64(x*RasterSize+i*h.Step))/scale,
+				(float64(y*RasterSize+j*h.Step))/scale,
+			)
+			samples[j*n+i], valid[j*n+i] = value(lat, lng)
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, RasterSize, RasterSize))
+	alpha := uint8(math.Round(255 * math.Max(0, math.Min(1, h.Opacity))))
+	span := h.Max - h.Min
+	for py := 0; py < RasterSize; py++ {
+		j, fy := py/h.Step, float64(py%h.Step)/float64(h.Step)
+		for px := 0; px < RasterSize; px++ {
+			i, fx := px/h.Step, float64(px%h.Step)/float64(h.Step)
+			v, ok := interpolate(samples, valid, n, i, j, fx, fy)
+			if !ok {
+				continue
+			}
+			t := 0.5
+			if span != 0 {
+				t = (v - h.Min) / span
+			}
+			c := h.Ramp.At(t)
+			c.A = uint8(uint16(c.A) * uint16(alpha) / 255)
+			img.SetNRGBA(px, py, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// interpolate blends the valid corners of cell i, j. A pixel whose nearest corner has no
+// value is left empty, so coastlines follow the sample grid.
+func interpolate(samples []float64, valid []bool, n, i, j int, fx, fy float64) (float64, bool) {
+	nearest := (j+int(math.Round(fy)))*n + i + int(math.Round(fx))
+	if !valid[nearest] {
+		return 0, false
+	}
+	var sum, weight float64
+	for _, c := range [4]struct {
+		di, dj int
+		w      float64
+	}{
+		{0, 0, (1 - fx) * (1 - fy)},
+		{1, 0, fx * (1 - fy)},
+		{0, 1, (1 - fx) * fy},
+		{1, 1, fx * fy},
+	} {
+		k := (j+c.dj)*n + i + c.di
+		if valid[k] && c.w > 0 {
+			sum += samples[k] * c.w
+			weight += c.w
+		}
+	}
+	if weight == 0 {
+		return samples[nearest], true
+	}
+	return sum / weight, true
+}
+
+// DiskCache keeps encoded tiles under Dir/<key>/<z>/<x>/<y>.<ext>. The key must identify
+// everything the tile depends on; entries are never invalidated otherwise. When MaxKeys is
+// positive, starting a new key removes the least recently written keys beyond it, including
+// ones left by earlier runs. Only key directories holding the cacheMarker file the cache
+// writes are removed, so Dir may be shared with other files.
+type DiskCache struct {
+	Dir     string
+	Ext     string
+	MaxKeys int
+}
+
+// cacheMarker is written into every key directory to mark it as the cache's to prune.
+const cacheMarker = ".tile-cache-key"
+
+func (c DiskCache) path(key string, z, x, y int) string {
+	return filepath.Join(c.Dir, key, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+"."+c.Ext)
+}
+
+// Get returns a cached tile.
+func (c DiskCache) Get(key string, z, x, y int) ([]byte, bool) {
+	content, err := os.ReadFile(c.path(key, z, x, y))
+	return content, err == nil
+}
+
+// Put stores a tile, writing to a temporary file first so readers never see part of one.
+func (c DiskCache) Put(key string, z, x, y int, content []byte) error {
+	path := c.path(key, z, x, y)
+	keyDir := filepath.Join(c.Dir, key)
+	_, err := os.Stat(keyDir)
+	newKey := os.IsNotExist(err)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if newKey {
+		if err := os.WriteFile(filepath.Join(keyDir, cacheMarker), nil, 0644); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	os.Chtimes(keyDir, now, now)
+	if newKey && c.MaxKeys > 0 {
+		c.prune(key)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tile-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// prune removes the least recently written keys other than keep until MaxKeys remain.
+// Directories without the cacheMarker are not the cache's and are left alone.
+func (c DiskCache) prune(keep string) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return
+	}
+	type keyDir struct {
+		name    string
+		written time.Time
+	}
+	var keys []keyDir
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == keep {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(c.Dir, e.Name(), cacheMarker)); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, keyDir{e.Name(), info.ModTime()})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].written.After(keys[j].written) })
+	for _, k := range keys[min(c.MaxKeys-1, len(keys)):] {
+		os.RemoveAll(filepath.Join(c.Dir, k.name))
+	}
+}
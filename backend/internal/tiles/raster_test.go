@@ -0,0 +1,40 @@
+package tiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	unrelated := filepath.Join(dir, "not-a-tile-key")
+	if err := os.MkdirAll(unrelated, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(unrelated, old, old)
+
+	cache := DiskCache{Dir: dir, Ext: "png", MaxKeys: 2}
+	keys := []string{"0123456789abcdef", "123456789abcdef0", "23456789abcdef01"}
+	for i, key := range keys {
+		if err := cache.Put(key, 1, 0, 0, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+		written := time.Now().Add(time.Duration(i-len(keys)) * time.Minute)
+		os.Chtimes(filepath.Join(dir, key), written, written)
+	}
+
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("unrelated directory removed: %v", err)
+	}
+	if _, ok := cache.Get(keys[0], 1, 0, 0); ok {
+		t.Errorf("oldest key %s kept", keys[0])
+	}
+	for _, key := range keys[1:] {
+		if content, ok := cache.Get(key, 1, 0, 0); !ok || len(content) != 1 {
+			t.Errorf("key %s missing", key)
+		}
+	}
+}